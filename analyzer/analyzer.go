@@ -1,16 +1,19 @@
 package analyzer
 
 import "github.com/sashakoshka/arf/parser"
+import "github.com/sashakoshka/arf/diagnostic"
 
 type Analyzer struct {
         
 }
 
+/* Analyze performs semantic analysis on a module. Any mistakes found are
+ * reported to sink.
+ */
 func Analyze (
         module *parser.Module,
+        sink   diagnostic.Sink,
 ) (
-        warnCount int,
-        errorCount int,
         err error,
 ) {
         // analyzer := Analyzer {
@@ -26,5 +29,9 @@ func Analyze (
         // is, skim-parse the module and add it to the cache. then, recursively
         // analyze and resolve the referenced item and add it to the cache.
 
+        // when an identifier cannot be resolved, report it along with
+        // suggest.DidYouMean, passing in the names of every variable,
+        // function, and type that is in scope.
+
         return
 }
@@ -0,0 +1,77 @@
+package main
+
+import "os"
+import "flag"
+import "bytes"
+import "strings"
+import "testing"
+import "path/filepath"
+import "github.com/sashakoshka/arf/parser"
+import "github.com/sashakoshka/arf/analyzer"
+import "github.com/sashakoshka/arf/diagnostic"
+
+var update = flag.Bool (
+        "update", false,
+        "rewrite the .expected files in tests/broken instead of checking them")
+
+/* TestBroken checks that every broken module in tests/broken produces exactly
+ * the diagnostics in its .expected file, the same way that arf check would
+ * print them. If a module has a .flags file next to it, the -W flags in it are
+ * used as well. Run it with -update to rewrite the .expected files instead,
+ * after making sure that the new diagnostics are actually right.
+ */
+func TestBroken (test *testing.T) {
+        files, err := filepath.Glob("tests/broken/*.arf")
+        if err != nil { test.Fatal(err) }
+        if len(files) == 0 { test.Fatal("there are no broken modules") }
+
+        for _, file := range files {
+                name := strings.TrimSuffix(filepath.Base(file), ".arf")
+                modulePath := "tests/broken/" + name
+
+                actual := checkBroken(test, modulePath)
+                expectedPath := modulePath + ".expected"
+                if *update {
+                        err = os.WriteFile(expectedPath, []byte(actual), 0644)
+                        if err != nil { test.Fatal(err) }
+                        continue
+                }
+
+                expected, err := os.ReadFile(expectedPath)
+                if err != nil { test.Fatal(err) }
+                if actual != string(expected) {
+                        test.Errorf (
+                                "%s gives:\n%s\nexpected:\n%s",
+                                name, actual, expected)
+                }
+        }
+}
+
+/* checkBroken checks a module like arf check does, and returns everything that
+ * it would print.
+ */
+func checkBroken (test *testing.T, modulePath string) (printed string) {
+        buffer := &bytes.Buffer { }
+        output   = buffer
+        format   = "text"
+        verbose  = false
+        sarif    = nil
+        mistakes = &diagnostic.Counter {
+                Sink: &diagnostic.Renderer { Output: buffer },
+        }
+        policy = &diagnostic.Policy { Sink: mistakes }
+        defer func () { output = os.Stderr } ()
+
+        flags, err := os.ReadFile(modulePath + ".flags")
+        if err == nil {
+                global := flag.NewFlagSet("arf", flag.ContinueOnError)
+                _, err = parseWarningFlags (
+                        global, strings.Fields(string(flags)))
+                if err != nil { test.Fatal(err) }
+        }
+
+        module, err := parser.Parse(modulePath, false, policy)
+        if err == nil { analyzer.Analyze(module, policy) }
+        finish()
+        return buffer.String()
+}
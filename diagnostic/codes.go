@@ -0,0 +1,133 @@
+package diagnostic
+
+import "sort"
+import "embed"
+import "strings"
+
+/* These are the stable codes that identify each kind of diagnostic. Codes
+ * starting with E are errors, and codes starting with W are warnings. The
+ * first two digits say which stage of the compiler the diagnostic comes from:
+ * 00 for the module as a whole, 01 for the lexer, and 02 for the parser. Once
+ * a code has been given out it must never be reused for something else.
+ */
+const (
+        CodeInvalidModuleName = "E0001"
+        CodeUnreadable        = "E0002"
+        CodeEmptyModule       = "E0003"
+        CodeEmptyFile         = "E0004"
+        CodeNoCodeGen         = "E0005"
+        CodeTooManyErrors     = "E0006"
+        CodeWrongModule       = "E0007"
+
+        CodeBadIndentSize     = "E0101"
+        CodeBadEscape         = "E0102"
+        CodeBadRuneLength     = "E0103"
+        CodeBadDigit          = "E0104"
+        CodeBadNumber         = "E0105"
+        CodeNumberOverflow    = "E0106"
+        CodeUnterminated      = "E0107"
+
+        CodeUnexpectedToken   = "E0201"
+        CodeBadIndent         = "E0202"
+        CodeTooMuchIndent     = "E0203"
+        CodeSurpriseEOF       = "E0204"
+        CodeUnknownSection    = "E0205"
+        CodeDuplicateSection  = "E0206"
+        CodeUnknownQualifier  = "E0207"
+        CodeAfterExternal     = "E0208"
+        CodeBadReceiver       = "E0209"
+        CodeDuplicateVariable = "E0210"
+        CodeMutableInput      = "E0211"
+        CodeUnknownArgument   = "E0212"
+        CodeDottedDeclaration = "E0213"
+        CodeUnclosed          = "E0214"
+        CodeStrayBracket      = "E0215"
+        CodeNoReturnTargets   = "E0216"
+
+        CodeUnknownCategory   = "W0101"
+        CodeConfusable        = "W0102"
+
+        CodeUnknownDirective  = "W0201"
+        CodeImmutableOutput   = "W0202"
+        CodeDeepIndent        = "W0203"
+)
+
+/* categories maps the code of each warning to the name of the category it is
+ * in. Categories are used to turn warnings on and off, both from the command
+ * line and from within source files.
+ */
+var categories = map[string] string {
+        CodeUnknownCategory:  "unknown-category",
+        CodeConfusable:       "confusable",
+        CodeUnknownDirective: "unknown-directive",
+        CodeImmutableOutput:  "immutable-output",
+        CodeDeepIndent:       "deep-indent",
+}
+
+/* offByDefault lists the warning categories that are turned off unless they
+ * are asked for. Warnings about confusable names are mostly noise in code
+ * written by one person in one script, so they must be turned on with
+ * -Wconfusable.
+ */
+var offByDefault = map[string] bool {
+        "confusable": true,
+}
+
+/* IsOnByDefault returns whether warnings in the specified category are reported
+ * when nothing has turned them on or off.
+ */
+func IsOnByDefault (category string) (on bool) {
+        return !offByDefault[category]
+}
+
+/* GetCategory returns the name of the category that a warning code is in. If
+ * the code is not a warning, it returns an empty string.
+ */
+func GetCategory (code string) (category string) {
+        return categories[code]
+}
+
+/* GetCategories returns the names of every warning category, in order.
+ */
+func GetCategories () (names []string) {
+        for _, category := range categories {
+                names = append(names, category)
+        }
+        sort.Strings(names)
+        return
+}
+
+//go:embed explanations/*.txt
+var explanations embed.FS
+
+/* Explain returns a longer description of what a diagnostic code means, along
+ * with examples of code that causes it and how to fix it.
+ */
+func Explain (code string) (explanation string, exists bool) {
+        code = strings.ToUpper(code)
+        data, err := explanations.ReadFile("explanations/" + code + ".txt")
+        if err != nil { return "", false }
+        return string(data), true
+}
+
+/* GetCodes returns every code that has an explanation, in order.
+ */
+func GetCodes () (codes []string) {
+        entries, _ := explanations.ReadDir("explanations")
+        for _, entry := range entries {
+                codes = append (
+                        codes,
+                        strings.TrimSuffix(entry.Name(), ".txt"))
+        }
+        sort.Strings(codes)
+        return
+}
+
+/* GetSummary returns the first line of the explanation of a code, which
+ * briefly says what it is.
+ */
+func GetSummary (code string) (summary string) {
+        explanation, _ := Explain(code)
+        summary, _, _ = strings.Cut(explanation, "\n")
+        return
+}
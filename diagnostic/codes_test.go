@@ -0,0 +1,79 @@
+package diagnostic
+
+import "strings"
+import "testing"
+import "go/ast"
+import "go/token"
+import "go/parser"
+import "strconv"
+
+/* getDeclaredCodes reads codes.go, and returns the value of every constant in
+ * it whose name starts with Code.
+ */
+func getDeclaredCodes (test *testing.T) (codes map[string] string) {
+        file, err := parser.ParseFile (
+                token.NewFileSet(), "codes.go", nil, 0)
+        if err != nil { test.Fatal(err) }
+
+        codes = make(map[string] string)
+        for _, declaration := range file.Decls {
+                general, isGeneral := declaration.(*ast.GenDecl)
+                if !isGeneral || general.Tok != token.CONST { continue }
+
+                for _, spec := range general.Specs {
+                        value := spec.(*ast.ValueSpec)
+                        for index, name := range value.Names {
+                                if !strings.HasPrefix(name.Name, "Code") {
+                                        continue
+                                }
+                                literal := value.Values[index].(*ast.BasicLit)
+                                code, err := strconv.Unquote(literal.Value)
+                                if err != nil { test.Fatal(err) }
+                                codes[code] = name.Name
+                        }
+                }
+        }
+
+        if len(codes) == 0 { test.Fatal("no codes were found") }
+        return
+}
+
+/* TestCodes checks that every code has an explanation for arf explain to print,
+ * that every explanation belongs to a code, and that every warning is in a
+ * category.
+ */
+func TestCodes (test *testing.T) {
+        codes := getDeclaredCodes(test)
+
+        for code, name := range codes {
+                explanation, exists := Explain(code)
+                if !exists {
+                        test.Errorf (
+                                "%s (%s) has no explanation in " +
+                                "explanations/%s.txt", code, name, code)
+                        continue
+                }
+                if GetSummary(code) == "" || explanation == "" {
+                        test.Errorf("%s (%s) has no summary", code, name)
+                }
+
+                _, lowerExists := Explain(strings.ToLower(code))
+                if !lowerExists {
+                        test.Errorf("%s cannot be explained in lowercase", code)
+                }
+
+                isWarning := strings.HasPrefix(code, "W")
+                if isWarning && GetCategory(code) == "" {
+                        test.Errorf("%s (%s) has no category", code, name)
+                }
+                if !isWarning && GetCategory(code) != "" {
+                        test.Errorf("%s (%s) is not a warning", code, name)
+                }
+        }
+
+        for _, code := range GetCodes() {
+                if _, declared := codes[code]; !declared {
+                        test.Errorf("explanations/%s.txt has no code", code)
+                }
+        }
+}
@@ -0,0 +1,304 @@
+package diagnostic
+
+import "fmt"
+import "strings"
+import "github.com/sashakoshka/arf/lineFile"
+
+/* Severity determines how serious a diagnostic is.
+ */
+type Severity int
+
+const (
+        // SeverityWarning is for things that are probably mistakes, but do
+        // not stop the module from being compiled.
+        SeverityWarning Severity = iota
+
+        // SeverityError is for mistakes that stop the module from being
+        // compiled.
+        SeverityError
+
+        // SeverityFatal is for mistakes that stop the file or module from
+        // being processed any further.
+        SeverityFatal
+)
+
+/* NoPosition is used as the row and column of a diagnostic that concerns an
+ * entire file rather than a specific place in it.
+ */
+const NoPosition = -1
+
+/* Diagnostic describes a single mistake found in a module.
+ */
+type Diagnostic struct {
+        Severity Severity
+
+        // Code identifies what kind of mistake this is. It may be empty.
+        Code string
+
+        // Module is the name of the module that the mistake was found in.
+        Module string
+
+        // File is the file that the mistake was found in. If the mistake
+        // concerns the entire module, this is nil.
+        File *lineFile.LineFile
+
+        // Row, Column, EndRow, and EndColumn describe where in the file the
+        // mistake is. They start at zero, and EndColumn is exclusive. If the
+        // end is not after the start, the mistake is at a single column. If
+        // the mistake concerns the entire file, they are all NoPosition.
+        Row       int
+        Column    int
+        EndRow    int
+        EndColumn int
+
+        Message string
+
+        // Notes holds additional information that might help the user fix
+        // the mistake.
+        Notes []string
+
+        // Labels point to other places that are related to the mistake, such
+        // as where something was previously defined.
+        Labels []Label
+}
+
+/* Label is a secondary location attached to a diagnostic, along with a message
+ * describing why it is relevant. It may be in a different file than the
+ * diagnostic itself, as long as the file is within the same module.
+ */
+type Label struct {
+        File      *lineFile.LineFile
+        Row       int
+        Column    int
+        EndRow    int
+        EndColumn int
+
+        Message string
+}
+
+/* At creates a diagnostic that points to a specific place in a file.
+ */
+func At (
+        severity Severity,
+        code     string,
+        file     *lineFile.LineFile,
+        row      int,
+        column   int,
+        cause    ...interface {},
+) (
+        diagnostic Diagnostic,
+) {
+        return Diagnostic {
+                Severity:  severity,
+                Code:      code,
+                Module:    file.GetModule(),
+                File:      file,
+                Row:       row,
+                Column:    column,
+                EndRow:    row,
+                EndColumn: column,
+                Message:   message(cause...),
+        }
+}
+
+/* InFile creates a diagnostic that concerns an entire file.
+ */
+func InFile (
+        severity Severity,
+        code     string,
+        file     *lineFile.LineFile,
+        cause    ...interface {},
+) (
+        diagnostic Diagnostic,
+) {
+        return Diagnostic {
+                Severity:  severity,
+                Code:      code,
+                Module:    file.GetModule(),
+                File:      file,
+                Row:       NoPosition,
+                Column:    NoPosition,
+                EndRow:    NoPosition,
+                EndColumn: NoPosition,
+                Message:   message(cause...),
+        }
+}
+
+/* InModule creates a diagnostic that concerns an entire module.
+ */
+func InModule (
+        severity Severity,
+        code     string,
+        module   string,
+        cause    ...interface {},
+) (
+        diagnostic Diagnostic,
+) {
+        return Diagnostic {
+                Severity:  severity,
+                Code:      code,
+                Module:    module,
+                Row:       NoPosition,
+                Column:    NoPosition,
+                EndRow:    NoPosition,
+                EndColumn: NoPosition,
+                Message:   message(cause...),
+        }
+}
+
+/* WithNotes returns a copy of the diagnostic with the specified notes added to
+ * it.
+ */
+func (diagnostic Diagnostic) WithNotes (notes ...string) (result Diagnostic) {
+        result = diagnostic
+        result.Notes = append([]string { }, diagnostic.Notes...)
+        result.Notes = append(result.Notes, notes...)
+        return
+}
+
+/* WithLabel returns a copy of the diagnostic with a label pointing to the
+ * specified place added to it.
+ */
+func (diagnostic Diagnostic) WithLabel (
+        file   *lineFile.LineFile,
+        row    int,
+        column int,
+        cause  ...interface {},
+) (
+        result Diagnostic,
+) {
+        result = diagnostic
+        result.Labels = append([]Label { }, diagnostic.Labels...)
+        result.Labels = append(result.Labels, Label {
+                File:      file,
+                Row:       row,
+                Column:    column,
+                EndRow:    row,
+                EndColumn: column,
+                Message:   message(cause...),
+        })
+        return
+}
+
+/* GetPath returns the path of the file the label is in.
+ */
+func (label Label) GetPath () (path string) {
+        if label.File == nil { return "" }
+        return label.File.GetPath()
+}
+
+/* GetSpan returns the position of the label, in the same form as
+ * Diagnostic.GetSpan.
+ */
+func (label Label) GetSpan () (
+        startRow    int,
+        startColumn int,
+        endRow      int,
+        endColumn   int,
+) {
+        return getSpan (
+                label.Row, label.Column,
+                label.EndRow, label.EndColumn)
+}
+
+/* GetPath returns the path of the file the diagnostic is in, or an empty
+ * string if it concerns an entire module.
+ */
+func (diagnostic Diagnostic) GetPath () (path string) {
+        if diagnostic.File == nil { return "" }
+        return diagnostic.File.GetPath()
+}
+
+/* HasPosition returns whether the diagnostic points to a specific place in a
+ * file.
+ */
+func (diagnostic Diagnostic) HasPosition () (positioned bool) {
+        return diagnostic.File != nil && diagnostic.Row != NoPosition
+}
+
+/* GetSpan returns the position of the diagnostic in the form that most tools
+ * expect it: starting at one, with an exclusive end column. A span that starts
+ * and ends on the same row always has an end column greater than its start
+ * column.
+ */
+func (diagnostic Diagnostic) GetSpan () (
+        startRow    int,
+        startColumn int,
+        endRow      int,
+        endColumn   int,
+) {
+        return getSpan (
+                diagnostic.Row, diagnostic.Column,
+                diagnostic.EndRow, diagnostic.EndColumn)
+}
+
+func getSpan (
+        row       int,
+        column    int,
+        endRow    int,
+        endColumn int,
+) (
+        spanRow       int,
+        spanColumn    int,
+        spanEndRow    int,
+        spanEndColumn int,
+) {
+        if endRow < row { endRow = row }
+        if endRow == row && endColumn <= column { endColumn = column + 1 }
+        return row + 1, column + 1, endRow + 1, endColumn + 1
+}
+
+/* GetOffsets returns the span of the diagnostic as byte offsets from the start
+ * of its file, with an exclusive end. If the diagnostic does not point to a
+ * specific place, both are zero.
+ */
+func (diagnostic Diagnostic) GetOffsets () (start int, end int) {
+        if !diagnostic.HasPosition() { return 0, 0 }
+        return getOffsets (
+                diagnostic.File, diagnostic.Row, diagnostic.Column,
+                diagnostic.EndRow, diagnostic.EndColumn)
+}
+
+/* GetOffsets returns the span of the label as byte offsets, in the same form as
+ * Diagnostic.GetOffsets.
+ */
+func (label Label) GetOffsets () (start int, end int) {
+        if label.File == nil { return 0, 0 }
+        return getOffsets (
+                label.File, label.Row, label.Column,
+                label.EndRow, label.EndColumn)
+}
+
+func getOffsets (
+        file      *lineFile.LineFile,
+        row       int,
+        column    int,
+        endRow    int,
+        endColumn int,
+) (
+        start int,
+        end   int,
+) {
+        if endRow < row { endRow = row }
+        if endRow == row && endColumn <= column { endColumn = column + 1 }
+        start = file.GetOffset(row, column)
+        end   = file.GetOffset(endRow, endColumn)
+        return
+}
+
+/* message joins the cause of a diagnostic together in the same way that
+ * fmt.Println would.
+ */
+func message (cause ...interface {}) (text string) {
+        return strings.TrimSuffix(fmt.Sprintln(cause...), "\n")
+}
+
+func (severity Severity) ToString () (description string) {
+        switch severity {
+                case SeverityWarning: return "warning"
+                case SeverityError:   return "error"
+                case SeverityFatal:   return "fatal"
+
+                default: return "BUG"
+        }
+}
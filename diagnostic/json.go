@@ -0,0 +1,83 @@
+package diagnostic
+
+import "io"
+import "encoding/json"
+
+/* JSONWriter is a sink that writes each diagnostic to Output as a JSON object
+ * on its own line. Rows and columns start at one, and end columns are
+ * exclusive. Spans also have byte offsets, which start at zero.
+ */
+type JSONWriter struct {
+        Output io.Writer
+}
+
+type jsonSpan struct {
+        StartRow    int `json:"startRow"`
+        StartColumn int `json:"startColumn"`
+        EndRow      int `json:"endRow"`
+        EndColumn   int `json:"endColumn"`
+        StartOffset int `json:"startOffset"`
+        EndOffset   int `json:"endOffset"`
+}
+
+type jsonDiagnostic struct {
+        Code     string      `json:"code,omitempty"`
+        Severity string      `json:"severity"`
+        Module   string      `json:"module"`
+        File     string      `json:"file,omitempty"`
+        Span     *jsonSpan   `json:"span,omitempty"`
+        Message  string      `json:"message"`
+        Notes    []string    `json:"notes,omitempty"`
+        Labels   []jsonLabel `json:"labels,omitempty"`
+}
+
+type jsonLabel struct {
+        File    string   `json:"file"`
+        Span    jsonSpan `json:"span"`
+        Message string   `json:"message"`
+}
+
+func (writer *JSONWriter) Report (diagnostic Diagnostic) {
+        object := jsonDiagnostic {
+                Code:     diagnostic.Code,
+                Severity: diagnostic.Severity.ToString(),
+                Module:   diagnostic.Module,
+                File:     diagnostic.GetPath(),
+                Message:  diagnostic.Message,
+                Notes:    diagnostic.Notes,
+        }
+
+        if diagnostic.HasPosition() {
+                row, startColumn, endRow, endColumn := diagnostic.GetSpan()
+                startOffset, endOffset := diagnostic.GetOffsets()
+                object.Span = &jsonSpan {
+                        StartRow:    row,
+                        StartColumn: startColumn,
+                        EndRow:      endRow,
+                        EndColumn:   endColumn,
+                        StartOffset: startOffset,
+                        EndOffset:   endOffset,
+                }
+        }
+
+        for _, label := range diagnostic.Labels {
+                row, startColumn, endRow, endColumn := label.GetSpan()
+                startOffset, endOffset := label.GetOffsets()
+                object.Labels = append(object.Labels, jsonLabel {
+                        File: label.GetPath(),
+                        Span: jsonSpan {
+                                StartRow:    row,
+                                StartColumn: startColumn,
+                                EndRow:      endRow,
+                                EndColumn:   endColumn,
+                                StartOffset: startOffset,
+                                EndOffset:   endOffset,
+                        },
+                        Message: label.Message,
+                })
+        }
+
+        encoded, err := json.Marshal(object)
+        if err != nil { return }
+        writer.Output.Write(append(encoded, '\n'))
+}
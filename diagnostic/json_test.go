@@ -0,0 +1,50 @@
+package diagnostic
+
+import "bytes"
+import "testing"
+import "encoding/json"
+import "github.com/sashakoshka/arf/lineFile"
+
+func TestJSONWriterSpan (test *testing.T) {
+        file, err := lineFile.FromBytes (
+                "span.arf", "span", []byte("first line\nsecond line\n"))
+        if err != nil { test.Fatal(err) }
+
+        mistake := At(SeverityError, "E0000", file, 0, 6, "spans two lines")
+        mistake.EndRow    = 1
+        mistake.EndColumn = 6
+
+        output := &bytes.Buffer { }
+        writer := &JSONWriter { Output: output }
+        writer.Report(mistake)
+
+        var object jsonDiagnostic
+        err = json.Unmarshal(output.Bytes(), &object)
+        if err != nil { test.Fatal(err) }
+        if object.Span == nil { test.Fatal("diagnostic has no span") }
+
+        expected := jsonSpan {
+                StartRow:    1,
+                StartColumn: 7,
+                EndRow:      2,
+                EndColumn:   7,
+                StartOffset: 6,
+                EndOffset:   len("first line\nsecond"),
+        }
+        if *object.Span != expected {
+                test.Errorf("span is %+v, expected %+v", *object.Span, expected)
+        }
+}
+
+func TestGetSpanSingleColumn (test *testing.T) {
+        file, err := lineFile.FromBytes("span.arf", "span", []byte("line\n"))
+        if err != nil { test.Fatal(err) }
+
+        mistake := At(SeverityError, "E0000", file, 0, 2, "one column")
+        row, startColumn, endRow, endColumn := mistake.GetSpan()
+        if row != 1 || startColumn != 3 || endRow != 1 || endColumn != 4 {
+                test.Errorf (
+                        "span is %d:%d to %d:%d, expected 1:3 to 1:4",
+                        row, startColumn, endRow, endColumn)
+        }
+}
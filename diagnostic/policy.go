@@ -0,0 +1,78 @@
+package diagnostic
+
+/* Policy is a sink that decides which diagnostics are passed on to another
+ * sink. It can turn off categories of warnings, turn warnings into errors, and
+ * stop reporting diagnostics after a certain amount of errors.
+ */
+type Policy struct {
+        Sink Sink
+
+        // WarningsAsErrors causes all warnings that are not turned off to be
+        // reported as errors.
+        WarningsAsErrors bool
+
+        // MaxErrors is the amount of errors that will be reported before the
+        // policy stops passing on diagnostics. If it is zero, there is no
+        // limit.
+        MaxErrors int
+
+        // enabled holds the categories that have been turned on or off.
+        // Categories that are not in it are left as they are by default.
+        enabled map[string] bool
+        errors  int
+        stopped bool
+}
+
+/* Disable turns off all warnings in the specified category.
+ */
+func (policy *Policy) Disable (category string) {
+        policy.set(category, false)
+}
+
+/* Enable turns on all warnings in the specified category. Most categories are
+ * turned on by default, but some, such as confusable, are not.
+ */
+func (policy *Policy) Enable (category string) {
+        policy.set(category, true)
+}
+
+/* IsEnabled returns whether warnings in the specified category are reported.
+ */
+func (policy *Policy) IsEnabled (category string) (enabled bool) {
+        enabled, set := policy.enabled[category]
+        if set { return }
+        return IsOnByDefault(category)
+}
+
+func (policy *Policy) set (category string, enabled bool) {
+        if policy.enabled == nil {
+                policy.enabled = make(map[string] bool)
+        }
+        policy.enabled[category] = enabled
+}
+
+func (policy *Policy) Report (diagnostic Diagnostic) {
+        if policy.stopped { return }
+
+        if diagnostic.Severity == SeverityWarning {
+                if !policy.IsEnabled(GetCategory(diagnostic.Code)) { return }
+                if policy.WarningsAsErrors {
+                        diagnostic.Severity = SeverityError
+                        diagnostic = diagnostic.WithNotes (
+                                "this is a warning, but -Werror is on")
+                }
+        }
+
+        if diagnostic.Severity != SeverityWarning {
+                policy.errors ++
+        }
+        
+        policy.Sink.Report(diagnostic)
+
+        if policy.MaxErrors > 0 && policy.errors >= policy.MaxErrors {
+                policy.stopped = true
+                policy.Sink.Report (InModule (
+                        SeverityFatal, CodeTooManyErrors, diagnostic.Module,
+                        "too many errors, not reporting any more"))
+        }
+}
@@ -0,0 +1,31 @@
+package diagnostic
+
+import "testing"
+
+func TestPolicyDefaults (test *testing.T) {
+        collector := &Collector { }
+        policy    := &Policy { Sink: collector }
+
+        report := func (code string) (reported bool) {
+                before := len(collector.GetDiagnostics())
+                policy.Report (InModule (
+                        SeverityWarning, code, "module", "warning"))
+                return len(collector.GetDiagnostics()) > before
+        }
+
+        if report(CodeConfusable) {
+                test.Error("confusable is reported by default")
+        }
+        if !report(CodeDeepIndent) {
+                test.Error("deep-indent is not reported by default")
+        }
+
+        policy.Enable("confusable")
+        policy.Disable("deep-indent")
+        if !report(CodeConfusable) {
+                test.Error("confusable is not reported after being enabled")
+        }
+        if report(CodeDeepIndent) {
+                test.Error("deep-indent is reported after being disabled")
+        }
+}
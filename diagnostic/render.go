@@ -0,0 +1,128 @@
+package diagnostic
+
+import "io"
+import "os"
+import "fmt"
+import "strings"
+import "strconv"
+import "github.com/sashakoshka/arf/lineFile"
+
+/* Renderer is a sink that prints diagnostics out in a human readable format,
+ * optionally with ANSI color codes. DetectColor can be used to decide whether
+ * or not to enable them.
+ */
+type Renderer struct {
+        Output io.Writer
+        Color  bool
+}
+
+func (renderer *Renderer) Report (diagnostic Diagnostic) {
+        var kind string
+        switch diagnostic.Severity {
+        case SeverityWarning: kind = renderer.paint("33", "!!!")
+        case SeverityError:   kind = renderer.paint("31", "ERR")
+        case SeverityFatal:   kind = renderer.paint("31", "XXX")
+        }
+
+        if diagnostic.Code != "" {
+                kind += " " + renderer.paint("90", diagnostic.Code)
+        }
+
+        if diagnostic.File == nil {
+                fmt.Fprintln (
+                        renderer.Output,
+                        kind, renderer.paint("90", "in"), diagnostic.Module)
+        } else if !diagnostic.HasPosition() {
+                fmt.Fprintln (
+                        renderer.Output,
+                        kind, renderer.paint("90", "in"), diagnostic.GetPath(),
+                        renderer.paint("90", "of"), diagnostic.Module)
+        } else {
+                renderer.printLocation (
+                        kind, diagnostic.File,
+                        diagnostic.Row, diagnostic.Column,
+                        diagnostic.EndRow, diagnostic.EndColumn)
+        }
+
+        fmt.Fprintln(renderer.Output, "   ", diagnostic.Message)
+        for _, note := range diagnostic.Notes {
+                fmt.Fprintln (
+                        renderer.Output, "   ",
+                        renderer.paint("90", "note:"), note)
+        }
+
+        for _, label := range diagnostic.Labels {
+                renderer.printLocation (
+                        renderer.paint("34", "(i)"), label.File,
+                        label.Row, label.Column,
+                        label.EndRow, label.EndColumn)
+                fmt.Fprintln(renderer.Output, "   ", label.Message)
+        }
+}
+
+/* printLocation prints the header of a diagnostic or label that points to a
+ * specific place, along with the offending line and an arrow pointing to the
+ * column. If the end is after the column, everything up to it is underlined
+ * as well. Only the first line is shown, so a span that goes on to later lines
+ * is underlined up to the end of it.
+ */
+func (renderer *Renderer) printLocation (
+        kind      string,
+        file      *lineFile.LineFile,
+        row       int,
+        column    int,
+        endRow    int,
+        endColumn int,
+) {
+        fmt.Fprintln (
+                renderer.Output,
+                kind, renderer.paint("90", "in"), file.GetPath(),
+                renderer.paint ("34",
+                        strconv.Itoa(row + 1) + ":" +
+                        strconv.Itoa(column + 1)),
+                renderer.paint("90", "of"), file.GetModule())
+
+        if row >= file.GetLength() { return }
+        
+        indent := 0
+        lineValue := file.GetLine(row)
+        if endRow > row { endColumn = len([]rune(lineValue)) }
+        for i, ch := range lineValue {
+                indent = i
+                if ch != ' ' { break }
+        }
+        
+        fmt.Fprintln(renderer.Output, "   ", strings.TrimSpace(lineValue))
+
+        arrow := "    "
+        for column > indent {
+                arrow += "-"
+                column --
+                endColumn --
+        }
+        arrow += "^"
+        for endColumn > column + 1 {
+                arrow += "~"
+                endColumn --
+        }
+        fmt.Fprintln(renderer.Output, arrow)
+}
+
+/* paint wraps text in the ANSI color code specified, if color is enabled.
+ */
+func (renderer *Renderer) paint (code string, text string) (painted string) {
+        if !renderer.Color { return text }
+        return "\033[" + code + "m" + text + "\033[0m"
+}
+
+/* DetectColor returns whether or not colored diagnostics should be written to
+ * output. Color is used only if output is a terminal, and the NO_COLOR
+ * environment variable is not set to anything.
+ */
+func DetectColor (output *os.File) (color bool) {
+        if os.Getenv("NO_COLOR") != "" { return false }
+
+        info, err := output.Stat()
+        if err != nil { return false }
+        return info.Mode() & os.ModeCharDevice != 0
+}
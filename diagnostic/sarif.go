@@ -0,0 +1,217 @@
+package diagnostic
+
+import "io"
+import "path/filepath"
+import "encoding/json"
+
+/* SARIFWriter is a sink that collects diagnostics and writes them to Output as
+ * a SARIF 2.1.0 log when Flush is called.
+ */
+type SARIFWriter struct {
+        Output io.Writer
+
+        collector Collector
+}
+
+func (writer *SARIFWriter) Report (diagnostic Diagnostic) {
+        writer.collector.Report(diagnostic)
+}
+
+type sarifLog struct {
+        Schema  string     `json:"$schema"`
+        Version string     `json:"version"`
+        Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+        Tool       sarifTool     `json:"tool"`
+        Results    []sarifResult `json:"results"`
+
+        // ColumnKind says what columns are counted in. Arf counts runes,
+        // and SARIF counts UTF-16 code units unless it is told otherwise.
+        ColumnKind string        `json:"columnKind"`
+}
+
+type sarifTool struct {
+        Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+        Name           string      `json:"name"`
+        InformationURI string      `json:"informationUri"`
+        Rules          []sarifRule `json:"rules,omitempty"`
+}
+
+type sarifRule struct {
+        ID string `json:"id"`
+}
+
+type sarifMessage struct {
+        Text string `json:"text"`
+}
+
+type sarifResult struct {
+        RuleID           string           `json:"ruleId,omitempty"`
+        Level            string           `json:"level"`
+        Message          sarifMessage     `json:"message"`
+        Locations        []sarifLocation  `json:"locations,omitempty"`
+        RelatedLocations []sarifLocation  `json:"relatedLocations,omitempty"`
+        Properties       *sarifProperties `json:"properties,omitempty"`
+}
+
+type sarifLocation struct {
+        ID               int                    `json:"id,omitempty"`
+        Message          *sarifMessage          `json:"message,omitempty"`
+        PhysicalLocation *sarifPhysicalLocation `json:"physicalLocation,omitempty"`
+        LogicalLocations []sarifLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+        ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+        Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+        URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+        StartLine   int `json:"startLine"`
+        StartColumn int `json:"startColumn"`
+        EndLine     int `json:"endLine"`
+        EndColumn   int `json:"endColumn"`
+        ByteOffset  int `json:"byteOffset"`
+        ByteLength  int `json:"byteLength"`
+}
+
+type sarifLogicalLocation struct {
+        Name string `json:"name"`
+        Kind string `json:"kind"`
+}
+
+type sarifProperties struct {
+        Notes []string `json:"notes,omitempty"`
+}
+
+/* Flush writes out a SARIF log containing every diagnostic that has been
+ * reported so far.
+ */
+func (writer *SARIFWriter) Flush () (err error) {
+        run := sarifRun {
+                Tool: sarifTool { Driver: sarifDriver {
+                        Name:           "arf",
+                        InformationURI: "https://git.tebibyte.media/arf/arf",
+                } },
+                Results:    []sarifResult { },
+                ColumnKind: "unicodeCodePoints",
+        }
+
+        ruleSeen := make(map[string] bool)
+        for _, diagnostic := range writer.collector.GetDiagnostics() {
+                if diagnostic.Code != "" && !ruleSeen[diagnostic.Code] {
+                        ruleSeen[diagnostic.Code] = true
+                        run.Tool.Driver.Rules = append (
+                                run.Tool.Driver.Rules,
+                                sarifRule { ID: diagnostic.Code })
+                }
+                
+                run.Results = append(run.Results, sarifResultOf(diagnostic))
+        }
+
+        encoded, err := json.MarshalIndent (sarifLog {
+                Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+                Version: "2.1.0",
+                Runs:    []sarifRun { run },
+        }, "", "  ")
+        if err != nil { return }
+
+        _, err = writer.Output.Write(append(encoded, '\n'))
+        return
+}
+
+func sarifResultOf (diagnostic Diagnostic) (result sarifResult) {
+        result = sarifResult {
+                RuleID:  diagnostic.Code,
+                Level:   "error",
+                Message: sarifMessage { Text: diagnostic.Message },
+        }
+
+        if diagnostic.Severity == SeverityWarning {
+                result.Level = "warning"
+        }
+
+        location := sarifLocation {
+                LogicalLocations: []sarifLogicalLocation { {
+                        Name: diagnostic.Module,
+                        Kind: "module",
+                } },
+        }
+
+        if diagnostic.File != nil {
+                location.PhysicalLocation = &sarifPhysicalLocation {
+                        ArtifactLocation: sarifArtifactLocation {
+                                URI: sarifURIOf(diagnostic.GetPath()),
+                        },
+                }
+        }
+
+        if diagnostic.HasPosition() {
+                row, startColumn, endRow, endColumn := diagnostic.GetSpan()
+                startOffset, endOffset := diagnostic.GetOffsets()
+                location.PhysicalLocation.Region = &sarifRegion {
+                        StartLine:   row,
+                        StartColumn: startColumn,
+                        EndLine:     endRow,
+                        EndColumn:   endColumn,
+                        ByteOffset:  startOffset,
+                        ByteLength:  endOffset - startOffset,
+                }
+        }
+        
+        result.Locations = []sarifLocation { location }
+
+        for index, label := range diagnostic.Labels {
+                row, startColumn, endRow, endColumn := label.GetSpan()
+                startOffset, endOffset := label.GetOffsets()
+                physical := &sarifPhysicalLocation {
+                        ArtifactLocation: sarifArtifactLocation {
+                                URI: sarifURIOf(label.GetPath()),
+                        },
+                        Region: &sarifRegion {
+                                StartLine:   row,
+                                StartColumn: startColumn,
+                                EndLine:     endRow,
+                                EndColumn:   endColumn,
+                                ByteOffset:  startOffset,
+                                ByteLength:  endOffset - startOffset,
+                        },
+                }
+                
+                result.RelatedLocations = append (
+                        result.RelatedLocations,
+                        sarifLocation {
+                                ID:               index + 1,
+                                Message:          &sarifMessage {
+                                        Text: label.Message,
+                                },
+                                PhysicalLocation: physical,
+                        })
+        }
+
+        if len(diagnostic.Notes) > 0 {
+                result.Properties = &sarifProperties {
+                        Notes: diagnostic.Notes,
+                }
+        }
+
+        return
+}
+
+/* sarifURIOf converts a file path into a URI. Relative paths are left relative,
+ * so that they are resolved against wherever the log is being viewed from.
+ */
+func sarifURIOf (path string) (uri string) {
+        uri = filepath.ToSlash(path)
+        if filepath.IsAbs(path) { uri = "file://" + uri }
+        return
+}
@@ -0,0 +1,43 @@
+package diagnostic
+
+import "bytes"
+import "testing"
+import "encoding/json"
+import "github.com/sashakoshka/arf/lineFile"
+
+/* TestSARIFColumns checks that columns are counted in runes, and that the log
+ * says so, since SARIF counts UTF-16 code units by default.
+ */
+func TestSARIFColumns (test *testing.T) {
+        file, err := lineFile.FromBytes (
+                "columns.arf", "columns", []byte("😀 x\n"))
+        if err != nil { test.Fatal(err) }
+
+        output := &bytes.Buffer { }
+        writer := &SARIFWriter { Output: output }
+        writer.Report(At(SeverityError, "E0000", file, 0, 2, "after emoji"))
+        err = writer.Flush()
+        if err != nil { test.Fatal(err) }
+
+        var log struct {
+                Runs []sarifRun `json:"runs"`
+        }
+        err = json.Unmarshal(output.Bytes(), &log)
+        if err != nil { test.Fatal(err) }
+        if len(log.Runs) != 1 { test.Fatal("wrong number of runs") }
+
+        run := log.Runs[0]
+        if run.ColumnKind != "unicodeCodePoints" {
+                test.Errorf("column kind is %q", run.ColumnKind)
+        }
+        if len(run.Results) != 1 || len(run.Results[0].Locations) != 1 {
+                test.Fatal("wrong number of results")
+        }
+        region := run.Results[0].Locations[0].PhysicalLocation.Region
+        if region == nil { test.Fatal("result has no region") }
+        if region.StartColumn != 3 {
+                test.Errorf (
+                        "result starts at column %d, expected 3",
+                        region.StartColumn)
+        }
+}
@@ -0,0 +1,106 @@
+package diagnostic
+
+import "sort"
+
+/* Sink is anything that diagnostics can be reported to. The lexer, parser, and
+ * analyzer all report the mistakes they find to a sink instead of printing
+ * them.
+ */
+type Sink interface {
+        Report (diagnostic Diagnostic)
+}
+
+/* SinkFunc allows an ordinary function to be used as a sink.
+ */
+type SinkFunc func (diagnostic Diagnostic)
+
+func (sinkFunc SinkFunc) Report (diagnostic Diagnostic) {
+        sinkFunc(diagnostic)
+}
+
+/* Counter passes diagnostics on to another sink, and keeps count of how many
+ * warnings and errors go through it. Fatal errors are counted as errors.
+ */
+type Counter struct {
+        Sink Sink
+
+        Warnings int
+        Errors   int
+}
+
+func (counter *Counter) Report (diagnostic Diagnostic) {
+        if diagnostic.Severity == SeverityWarning {
+                counter.Warnings ++
+        } else {
+                counter.Errors ++
+        }
+
+        if counter.Sink != nil {
+                counter.Sink.Report(diagnostic)
+        }
+}
+
+/* Collector stores every diagnostic reported to it, so that they can be
+ * filtered, sorted, and rendered later.
+ */
+type Collector struct {
+        diagnostics []Diagnostic
+}
+
+func (collector *Collector) Report (diagnostic Diagnostic) {
+        collector.diagnostics = append(collector.diagnostics, diagnostic)
+}
+
+/* GetDiagnostics returns all diagnostics that have been collected, in the
+ * order that they were reported in.
+ */
+func (collector *Collector) GetDiagnostics () (diagnostics []Diagnostic) {
+        return collector.diagnostics
+}
+
+/* Filter returns all collected diagnostics that the specified function returns
+ * true for.
+ */
+func (collector *Collector) Filter (
+        keep func (diagnostic Diagnostic) bool,
+) (
+        diagnostics []Diagnostic,
+) {
+        for _, diagnostic := range collector.diagnostics {
+                if keep(diagnostic) {
+                        diagnostics = append(diagnostics, diagnostic)
+                }
+        }
+        return
+}
+
+/* Sort sorts the collected diagnostics by module, file, and then position.
+ * Diagnostics that concern an entire module or file come before ones that
+ * point to a specific place.
+ */
+func (collector *Collector) Sort () {
+        sort.SliceStable(collector.diagnostics, func (left, right int) bool {
+                return less (
+                        collector.diagnostics[left],
+                        collector.diagnostics[right])
+        })
+}
+
+/* Replay reports every collected diagnostic to another sink, in order.
+ */
+func (collector *Collector) Replay (sink Sink) {
+        for _, diagnostic := range collector.diagnostics {
+                sink.Report(diagnostic)
+        }
+}
+
+func less (left, right Diagnostic) (isLess bool) {
+        if left.Module != right.Module { return left.Module < right.Module }
+
+        leftPath  := left.GetPath()
+        rightPath := right.GetPath()
+        if leftPath != rightPath { return leftPath < rightPath }
+
+        if left.Row != right.Row { return left.Row < right.Row }
+        return left.Column < right.Column
+}
@@ -0,0 +1,62 @@
+package diagnostic
+
+import "github.com/sashakoshka/arf/lineFile"
+
+/* Suppression allows categories of warnings within a range of rows in a file.
+ * Suppressions come from comments in the source code.
+ */
+type Suppression struct {
+        File       *lineFile.LineFile
+        StartRow   int
+        EndRow     int
+        Categories []string
+}
+
+/* Suppressor is a sink that drops warnings that have been allowed by a
+ * suppression, and passes everything else on to another sink.
+ */
+type Suppressor struct {
+        Sink Sink
+
+        suppressions []Suppression
+}
+
+/* Add adds suppressions to the suppressor.
+ */
+func (suppressor *Suppressor) Add (suppressions ...Suppression) {
+        suppressor.suppressions = append (
+                suppressor.suppressions,
+                suppressions...)
+}
+
+func (suppressor *Suppressor) Report (diagnostic Diagnostic) {
+        if diagnostic.Severity == SeverityWarning &&
+                suppressor.suppressed(diagnostic) { return }
+        suppressor.Sink.Report(diagnostic)
+}
+
+/* suppressed returns whether or not a warning has been allowed.
+ */
+func (suppressor *Suppressor) suppressed (
+        diagnostic Diagnostic,
+) (
+        suppressed bool,
+) {
+        category := GetCategory(diagnostic.Code)
+        if category == "" || diagnostic.File == nil { return false }
+
+        for _, suppression := range suppressor.suppressions {
+                if suppression.File != diagnostic.File { continue }
+                
+                inRange :=
+                        diagnostic.Row >= suppression.StartRow &&
+                        diagnostic.Row <= suppression.EndRow
+                if !inRange { continue }
+
+                for _, allowed := range suppression.Categories {
+                        if allowed == category { return true }
+                }
+        }
+
+        return false
+}
@@ -1,12 +1,13 @@
 package lexer
 
 import "fmt"
-import "math"
 import "errors"
 import "strings"
 import "strconv"
+import "unicode"
 import "github.com/sashakoshka/arf/lineFile"
 import "github.com/sashakoshka/arf/validate"
+import "github.com/sashakoshka/arf/diagnostic"
 
 /* TokenKind is an enum represzenting what type a token is.
  */
@@ -45,8 +46,14 @@ type Lexer struct {
         lineNumber int
         line       *Line
 
-        warnCount  int
-        errorCount int
+        // end is the row that the lexer stops before.
+        end        int
+
+        // comments holds whole-line comments that have not been attached to a
+        // line yet.
+        comments []*Comment
+
+        sink diagnostic.Sink
 }
 
 /* Line represents a line of a file. Its primary purpose is to store tokens.
@@ -66,25 +73,85 @@ type Line struct {
 
         // Tokens is an array of all tokens extracted from the line.
         Tokens []*Token
+
+        // Comments holds the whole-line comments that come directly before the
+        // line, and Trailing is the comment at the end of the line, if there
+        // is one.
+        Comments []*Comment
+        Trailing *Comment
+}
+
+/* Comment is a comment in the source code. Comments are not tokens, but they
+ * are kept alongside them so that they are not lost to tools like formatters.
+ * Comments at the very end of a file, with no code after them, are dropped.
+ */
+type Comment struct {
+        Row    int
+        Column int
+
+        // Text is the entire comment, including the # that starts it.
+        Text string
 }
 
+/* Token is a single token extracted from a line. Its span starts at Row and
+ * Column and ends right before EndRow and EndColumn, which only differ from the
+ * row of the line it is in for multi-line strings. Offset and EndOffset are the
+ * same span, as byte offsets from the start of the file.
+ */
 type Token struct {
         Kind        TokenKind
         StringValue string
         Value       interface {}
+        
+        Row         int
         Column      int
+        EndRow      int
+        EndColumn   int
+        Offset      int
+        EndOffset   int
+
+        // Suffix is the type suffix of a number literal, such as u8 or i32.
+        // If there is none, it is empty.
+        Suffix string
 }
 
+/* Tokenize splits a file up into lines of tokens. Any mistakes found are
+ * reported to sink.
+ */
 func Tokenize (
-        file       *lineFile.LineFile,
-        moduleName string,
+        file *lineFile.LineFile,
+        sink diagnostic.Sink,
 ) (
         lines []*Line,
-        warnCount  int,
-        errorCount int,
-        err        error,
+        err   error,
 ) {
-        lexer := &Lexer { file: file }
+        lines, _, err = TokenizeRows(file, 0, file.GetLength(), sink)
+        return
+}
+
+/* TokenizeRows is like Tokenize, but it only splits up the rows from startRow
+ * up to endRow. Whole-line comments that come after the last line, and so have
+ * not been attached to anything, are returned as dangling.
+ */
+func TokenizeRows (
+        file     *lineFile.LineFile,
+        startRow int,
+        endRow   int,
+        sink     diagnostic.Sink,
+) (
+        lines    []*Line,
+        dangling []*Comment,
+        err      error,
+) {
+        lexer := &Lexer {
+                file:       file,
+                sink:       sink,
+                lineNumber: startRow - 1,
+                end:        endRow,
+        }
+
+        // the first row is always the magic bytes, so it is skipped
+        if lexer.lineNumber < 0 { lexer.lineNumber = 0 }
 
         done := false
         for {
@@ -97,7 +164,7 @@ func Tokenize (
                 line.runes = nil
         }
         
-        return lexer.lines, lexer.warnCount, lexer.errorCount, err
+        return lexer.lines, lexer.comments, err
 }
 
 func (lexer *Lexer) tokenizeLine () (done bool, err error) {
@@ -118,14 +185,15 @@ func (lexer *Lexer) tokenizeLine () (done bool, err error) {
         for line.notEnd() {
                 // make a crude guess at the token based on the first rune
                 ch := line.ch()
+                count := len(line.Tokens)
 
-                lowercase := ch >= 'a' && ch <= 'z'
-                uppercase := ch >= 'A' && ch <= 'Z'
-                number    := ch >= '0' && ch <= '9'
+                number := ch >= '0' && ch <= '9'
                 
                 if number {
                         lexer.tokenizeNumber(false)
-                } else if lowercase || uppercase {
+                } else if unicode.IsDigit(ch) {
+                        lexer.tokenizeForeignNumber()
+                } else if validate.IsNameStart(ch) {
                         lexer.tokenizeMulti()
                 } else {
                         switch ch {
@@ -137,6 +205,10 @@ func (lexer *Lexer) tokenizeLine () (done bool, err error) {
                                 lexer.tokenizeString('\'')
                                 line.nextRune()
                                 break
+                        case '`':
+                                lexer.tokenizeString('`')
+                                line.nextRune()
+                                break
                         case ':':
                                 line.add(TokenKindColon, ":", ":")
                                 line.nextRune()
@@ -161,27 +233,61 @@ func (lexer *Lexer) tokenizeLine () (done bool, err error) {
                                 line.add(TokenKindRBrace, "}", "}")
                                 line.nextRune()
                                 break
+                        case '#':
+                                lexer.tokenizeComment()
+                                break
                         default:
                                 lexer.tokenizeSymbol()
                                 break
                         }
                 }
 
+                if len(line.Tokens) > count {
+                        lexer.finishToken(line.Tokens[len(line.Tokens) - 1])
+                }
+
                 lexer.skipWhitespace()
         }
 
         if len(line.Tokens) > 0 {
+                line.Comments = lexer.comments
+                lexer.comments = nil
                 lexer.lines = append(lexer.lines, line)
         }
 
         return
 }
 
+/* finishToken fills in the span of a token that has just been tokenized, which
+ * ends where the line currently is, unless it has already been set.
+ */
+func (lexer *Lexer) finishToken (token *Token) {
+        line := lexer.line
+        
+        token.Row = line.Row
+        if token.EndColumn == 0 {
+                end := line.index
+                if end > len(line.runes) { end = len(line.runes) }
+                token.EndRow    = line.Row
+                token.EndColumn = end + line.Column
+        }
+
+        token.Offset    = lexer.file.GetOffset(token.Row,    token.Column)
+        token.EndOffset = lexer.file.GetOffset(token.EndRow, token.EndColumn)
+}
+
 func (line *Line) ch () (ch rune) {
         if !line.notEnd() { return '\000' }
         return line.runes[line.index]
 }
 
+/* peek returns the rune after the current one, without moving on to it.
+ */
+func (line *Line) peek () (ch rune) {
+        if line.index + 1 >= len(line.runes) { return '\000' }
+        return line.runes[line.index + 1]
+}
+
 func (line *Line) nextRune () {
         line.index ++
 }
@@ -214,6 +320,11 @@ func (line *Line) GetLength () (length int) {
 func (line *Line) Dump () {
         var kind string
 
+        for _, comment := range line.Comments {
+                for i := 0; i < line.Indent; i++ { fmt.Print("        ") }
+                fmt.Println(comment.Text)
+        }
+
         for i := 0; i < line.Indent; i++ { fmt.Print("        ") }
         fmt.Println("line", line.Row)
         for _, token := range line.Tokens {
@@ -239,131 +350,45 @@ func (line *Line) Dump () {
                 for i := 0; i < line.Indent; i++ { fmt.Print("        ") }
                 fmt.Println("-", kind, token.Value)
         }
-}
 
-func (lexer *Lexer) tokenizeNumber (negative bool) {
-        line := lexer.line
-
-        radix := 10
-        isFloat := false
-        floatPosition := 0
-
-        token := Token { Column: line.index + line.Column }
-
-        if line.ch() == '0' {
-                line.nextRune()
-                switch line.ch() {
-                case 'x':
-                        // hexidecimal
-                        radix = 16
-                        line.nextRune()
-                        for line.notEnd() {
-                                ch := line.ch()
-                                notLower := ch < 'a' || ch > 'f'
-                                notUpper := ch < 'A' || ch > 'F'
-                                notNum   := ch < '0' || ch > '9'
-                                dot := ch == '.'
-                                if notLower && notUpper && notNum && !dot {
-                                        break
-                                }
-
-                                line.nextRune()
-                                if dot {
-                                        isFloat = true
-                                        floatPosition = len(token.StringValue)
-                                } else {
-                                        token.StringValue += string(ch)
-                                }
-                        }
-                        break
-                        
-                case 'b':
-                        // binary
-                        radix = 2
-                        line.nextRune()
-                        for line.notEnd() {
-                                ch := line.ch()
-                                if ch < '0' || ch > '1' { break }
-                                token.StringValue += string(ch)
-                                line.nextRune()
-                        }
-                        break
-                        
-                default:
-                        // octal
-                        radix = 8
-                        for line.notEnd() {
-                                ch := line.ch()
-                                notNum := ch < '0' || ch > '7'
-                                dot := ch == '.'
-                                if notNum && !dot { break }
-
-                                line.nextRune()
-                                if dot {
-                                        isFloat = true
-                                        floatPosition = len(token.StringValue)
-                                } else {
-                                        token.StringValue += string(ch)
-                                }
-                        }
-                        break
-                }
-        } else {
-                // decimal
-                for line.notEnd() {
-                        ch := line.ch()
-                        notNum := ch < '0' || ch > '9'
-                        dot := ch == '.'
-                        if notNum && !dot { break }
-
-                        line.nextRune()
-                        if dot {
-                                isFloat = true
-                                floatPosition = len(token.StringValue)
-                        } else {
-                                token.StringValue += string(ch)
-                        }
-                }
-        }
-
-        parsedNumber, _ := strconv.ParseUint (
-                token.StringValue, radix, 64)
-        floatValue := float64(parsedNumber)
-        if negative {
-                token.Kind  = TokenKindSignedInteger
-                token.Value = int64(parsedNumber) * -1
-                floatValue *= -1
-        } else {
-                token.Kind  = TokenKindInteger
-                token.Value = parsedNumber
-        }
-
-        if isFloat {
-                token.Kind  = TokenKindFloat
-                floatPosition = len(token.StringValue) - floatPosition
-                coefficient := math.Pow(float64(radix), float64(floatPosition))
-                floatValue /= coefficient
-                token.Value = floatValue
+        if line.Trailing != nil {
+                for i := 0; i < line.Indent; i++ { fmt.Print("        ") }
+                fmt.Println("- Comment", line.Trailing.Text)
         }
-        
-        line.addExisting(&token)
 }
 
+/* tokenizeString tokenizes a string or rune literal. Strings wrapped in
+ * backticks are raw, and have no escape sequences. If a string starts with
+ * three quotes or backticks at the end of a line, it is a multi-line string.
+ */
 func (lexer *Lexer) tokenizeString (terminator rune) {
         line := lexer.line
 
         token := Token {
                 Column: line.index + line.Column,
         }
+        start := line.index
+        raw := terminator == '`'
 
         line.nextRune()
+        rest := string(line.runes[line.index:])
+        tripled := string([]rune { terminator, terminator })
+        if terminator != '\'' && rest == tripled {
+                lexer.tokenizeMultiLineString(&token, raw)
+                return
+        }
+        
+        closed := false
         for line.notEnd() {
                 ch := line.ch()
 
-                if ch == terminator { break }
-                if ch == '\\' {
-                        err := line.getEscapeSequence(&token)
-                        if err != nil { lexer.printError(line.index, err) }
+                if ch == terminator {
+                        closed = true
+                        break
+                }
+                
+                if ch == '\\' && !raw {
+                        lexer.tokenizeEscapeSequence(&token)
                         continue
                 }
                 
@@ -371,13 +396,25 @@ func (lexer *Lexer) tokenizeString (terminator rune) {
                 line.nextRune()
         }
 
+        if !closed {
+                description := "string literal is never closed"
+                if terminator == '\'' {
+                        description = "rune literal is never closed"
+                }
+                lexer.printError (
+                        diagnostic.CodeUnterminated, start, line.index,
+                        description)
+        }
+
         if terminator == '\'' {
                 runes := []rune(token.StringValue)
                 if len(runes) == 1 {
                         token.Value = runes[0]
                 } else {
+                        end := line.index
+                        if closed { end ++ }
                         lexer.printError (
-                                line.index - 1,
+                                diagnostic.CodeBadRuneLength, start, end,
                                 "rune literal must be one rune in size")
                         token.Value = '\000'
                 }
@@ -391,6 +428,110 @@ func (lexer *Lexer) tokenizeString (terminator rune) {
         line.addExisting(&token)
 }
 
+/* tokenizeMultiLineString tokenizes the content of a multi-line string. The
+ * string is made up of every line after the current one that is indented
+ * further than it, joined together by line breaks. One level of indentation
+ * more than the current line is taken off of each of them, and any more than
+ * that is kept. This is the same way that default values continue on to more
+ * indented lines.
+ */
+func (lexer *Lexer) tokenizeMultiLineString (token *Token, raw bool) {
+        line := lexer.line
+        line.index = len(line.runes)
+
+        startIndent   := line.Column
+        contentIndent := startIndent + 8
+
+        var lines []string
+        blanks  := 0
+        lastRow := line.Row
+        for {
+                row := lexer.lineNumber + 1
+                if row >= lexer.end { break }
+
+                text := lexer.file.GetLine(row)
+                trimmed := strings.TrimLeft(text, " ")
+                indent := len(text) - len(trimmed)
+
+                // blank lines only count if there is more of the string
+                // after them
+                if trimmed == "" {
+                        blanks ++
+                        lexer.lineNumber = row
+                        continue
+                }
+                if indent <= startIndent { break }
+
+                for ; blanks > 0; blanks -- { lines = append(lines, "") }
+
+                strip := indent
+                if strip > contentIndent { strip = contentIndent }
+                
+                content := &Line {
+                        runes:  []rune(text[strip:]),
+                        Row:    row,
+                        Column: strip,
+                }
+                lexer.lineNumber = row
+                lastRow = row
+                lines = append(lines, lexer.tokenizeLineContent(content, raw))
+        }
+
+        lexer.line = line
+        if len(lines) > 0 {
+                token.EndRow    = lastRow
+                token.EndColumn = len([]rune(lexer.file.GetLine(lastRow)))
+        }
+        
+        token.StringValue = strings.Join(lines, "\n")
+        token.Value = token.StringValue
+        token.Kind  = TokenKindString
+        line.addExisting(token)
+}
+
+/* tokenizeLineContent returns the content of a single line of a multi-line
+ * string, with escape sequences processed unless the string is raw.
+ */
+func (lexer *Lexer) tokenizeLineContent (
+        content *Line,
+        raw bool,
+) (
+        text string,
+) {
+        if raw { return string(content.runes) }
+        
+        lexer.line = content
+        token := Token { }
+        for content.notEnd() {
+                if content.ch() == '\\' {
+                        lexer.tokenizeEscapeSequence(&token)
+                        continue
+                }
+
+                token.StringValue += string(content.ch())
+                content.nextRune()
+        }
+
+        return token.StringValue
+}
+
+/* tokenizeEscapeSequence adds the escape sequence at the current position of
+ * the line to a token, and reports it if it is invalid.
+ */
+func (lexer *Lexer) tokenizeEscapeSequence (token *Token) {
+        line := lexer.line
+        start := line.index
+        
+        err := line.getEscapeSequence(token)
+        if err != nil {
+                // an invalid escape code is left to be read as part of the
+                // string, but it is still part of the mistake
+                end := line.index
+                if end < start + 2 && line.notEnd() { end = start + 2 }
+                lexer.printError(diagnostic.CodeBadEscape, start, end, err)
+        }
+}
+
 var escapeCodeMap = map[rune] rune {
         'a':  '\x07',
         'b':  '\x08',
@@ -412,6 +553,7 @@ func (line *Line) getEscapeSequence (token *Token) (err error) {
         if exists {
                 // simple escape sequence
                 token.StringValue += string(code)
+                line.nextRune()
                 
         } else if ch >= '0' && ch <= '7' {
                 // octal escape sequence
@@ -431,7 +573,7 @@ func (line *Line) getEscapeSequence (token *Token) (err error) {
                 }
 
                 parsedNumber, _ := strconv.ParseInt(number, 8, 8)
-                token.StringValue += string(parsedNumber)
+                token.StringValue += string(rune(parsedNumber))
                 
         } else if ch == 'x' || ch == 'u' || ch == 'U' {
                 // hexidecimal escape sequence
@@ -458,7 +600,7 @@ func (line *Line) getEscapeSequence (token *Token) (err error) {
                 }
 
                 parsedNumber, _ := strconv.ParseInt(number, 16, want * 4)
-                token.StringValue += string(parsedNumber)
+                token.StringValue += string(rune(parsedNumber))
                 
         } else {
                 return errors.New("invalid escape code \\" + string(ch))
@@ -478,8 +620,7 @@ func (lexer *Lexer) tokenizeSymbol () {
                 ch := line.ch()
 
                 // *breathes in*
-                if ch >= 'a' && ch <= 'z' { break }
-                if ch >= 'A' && ch <= 'Z' { break }
+                if validate.IsNameStart(ch) { break }
                 if ch >= '0' && ch <= '9' {
                         // we may in fact be parsing a negative number!
                         if (token.StringValue == "-") {
@@ -488,15 +629,18 @@ func (lexer *Lexer) tokenizeSymbol () {
                         }
                         break
                 }
+                if unicode.IsDigit(ch) { break }
                 if ch == ' '  { break }
                 if ch == '"'  { break }
                 if ch == '\'' { break }
+                if ch == '`'  { break }
                 if ch == ':'  { break }
                 if ch == '.'  { break }
                 if ch == '['  { break }
                 if ch == ']'  { break }
                 if ch == '{'  { break }
                 if ch == '}'  { break }
+                if ch == '#'  { break }
                 // AHHHHHHHHHHHHHHHHHHHH
 
                 token.StringValue += string(ch)
@@ -526,19 +670,24 @@ func (lexer *Lexer) tokenizeMulti () {
         
         for line.notEnd() {
                 ch := line.ch()
-                lowercase := ch >= 'a' && ch <= 'z'
-                uppercase := ch >= 'A' && ch <= 'Z'
-                number    := ch >= '0' && ch <= '9'
-
-                if !lowercase && !uppercase && !number {
-                        break
-                }
+                if !validate.IsNameContinue(ch) { break }
 
                 token.StringValue += string(ch)
 
                 line.nextRune()
         }
 
+        // names that mix scripts, such as Latin and Cyrillic, can look exactly
+        // like other names
+        scripts := validate.GetMixedScripts(token.StringValue)
+        if scripts != nil {
+                lexer.printWarning (
+                        diagnostic.CodeConfusable,
+                        token.Column - line.Column, line.index,
+                        "name \"" + token.StringValue + "\" mixes",
+                        describeScripts(scripts), "letters")
+        }
+
         if validate.ValidatePermission(token.StringValue) {
                 token.Kind  = TokenKindPermission
                 token.Value = token.StringValue
@@ -550,6 +699,33 @@ func (lexer *Lexer) tokenizeMulti () {
         line.addExisting(&token)
 }
 
+/* tokenizeComment takes the rest of the line as a trailing comment. The line
+ * ends where the code before the comment does.
+ */
+func (lexer *Lexer) tokenizeComment () {
+        line := lexer.line
+
+        line.Trailing = &Comment {
+                Row:    line.Row,
+                Column: line.index + line.Column,
+                Text:   string(line.runes[line.index:]),
+        }
+
+        code := strings.TrimRight(string(line.runes[:line.index]), " ")
+        line.EndColumn = line.Column + len([]rune(code))
+        line.index = len(line.runes)
+}
+
+/* describeScripts lists the names of several scripts in plain english, such as
+ * "Cyrillic, Greek, and Latin".
+ */
+func describeScripts (scripts []string) (description string) {
+        if len(scripts) == 2 { return scripts[0] + " and " + scripts[1] }
+        
+        last := len(scripts) - 1
+        return strings.Join(scripts[:last], ", ") + ", and " + scripts[last]
+}
+
 func (lexer *Lexer) skipWhitespace () {
         line := lexer.line
         for line.notEnd() && line.ch() == ' ' {
@@ -563,7 +739,7 @@ func (lexer *Lexer) skipWhitespace () {
 func (lexer *Lexer) nextLine () (done bool, ignore bool, err error) {
         lexer.lineNumber ++
         
-        if lexer.lineNumber >= lexer.file.GetLength() {
+        if lexer.lineNumber >= lexer.end {
                 return true, true, nil
         }
         
@@ -578,7 +754,16 @@ func (lexer *Lexer) nextLine () (done bool, ignore bool, err error) {
         lineValue := lexer.file.GetLine(lexer.lineNumber)
         for i, ch := range lineValue {
                 line.Indent = i
-                if ch == '#' { return false, true, nil }
+                if ch == '#' {
+                        // whole-line comments are kept until there is a line
+                        // they can be attached to
+                        lexer.comments = append(lexer.comments, &Comment {
+                                Row:    lexer.lineNumber,
+                                Column: i,
+                                Text:   strings.TrimSpace(lineValue[i:]),
+                        })
+                        return false, true, nil
+                }
                 if ch != ' ' { break }
         }
         line.Column = line.Indent
@@ -588,7 +773,7 @@ func (lexer *Lexer) nextLine () (done bool, ignore bool, err error) {
         if line.Indent % 8 != 0 {
                 line.Indent /= 8
                 lexer.printError (
-                        0,
+                        diagnostic.CodeBadIndentSize, 0, 0,
                         "malformed indentation, use indentation size of 8",
                         "spaces")
                 return false, true, nil
@@ -597,23 +782,56 @@ func (lexer *Lexer) nextLine () (done bool, ignore bool, err error) {
         return
 }
 
-func (lexer *Lexer) printWarning (column int, cause ...interface {}) {
-        lexer.warnCount ++
-        lexer.file.PrintWarning (
-                column + lexer.line.Column,
-                lexer.lineNumber, cause...)
+/* printWarning reports a warning that spans from column up to endColumn on the
+ * current line. Both are counted from the start of the line's code, like
+ * line.index is.
+ */
+func (lexer *Lexer) printWarning (
+        code      string,
+        column    int,
+        endColumn int,
+        cause     ...interface {},
+) {
+        lexer.sink.Report (lexer.diagnose (
+                diagnostic.SeverityWarning, code, column, endColumn,
+                cause...))
 }
 
-func (lexer *Lexer) printError (column int, cause ...interface {}) {
-        lexer.errorCount ++
-        lexer.file.PrintError (
-                column + lexer.line.Column,
-                lexer.lineNumber, cause...)
+/* printError reports an error in the same way that printWarning reports a
+ * warning.
+ */
+func (lexer *Lexer) printError (
+        code      string,
+        column    int,
+        endColumn int,
+        cause     ...interface {},
+) {
+        lexer.sink.Report (lexer.diagnose (
+                diagnostic.SeverityError, code, column, endColumn,
+                cause...))
+}
+
+func (lexer *Lexer) diagnose (
+        severity  diagnostic.Severity,
+        code      string,
+        column    int,
+        endColumn int,
+        cause     ...interface {},
+) (
+        mistake diagnostic.Diagnostic,
+) {
+        mistake = diagnostic.At (
+                severity, code, lexer.file,
+                lexer.lineNumber, column + lexer.line.Column,
+                cause...)
+        mistake.EndColumn = endColumn + lexer.line.Column
+        return
 }
 
 func (lexer *Lexer) printFatal (err error) {
-        lexer.errorCount ++
-        lexer.file.PrintFatal("could not tokenize module -", err)
+        lexer.sink.Report (diagnostic.InFile (
+                diagnostic.SeverityFatal, diagnostic.CodeUnreadable,
+                lexer.file, "could not tokenize module -", err))
 }
 
 func (tokenKind TokenKind) ToString () (description string) {
@@ -0,0 +1,381 @@
+package lexer
+
+import "math"
+import "strconv"
+import "strings"
+import "unicode"
+import "github.com/sashakoshka/arf/suggest"
+import "github.com/sashakoshka/arf/validate"
+import "github.com/sashakoshka/arf/diagnostic"
+
+/* numberSuffixes maps each suffix that a number literal can end in to the size
+ * of the type it gives the number, in bits.
+ */
+var numberSuffixes = map[string] int {
+        "u8":  8,  "u16": 16, "u32": 32, "u64": 64,
+        "i8":  8,  "i16": 16, "i32": 32, "i64": 64,
+        "f32": 32, "f64": 64,
+}
+
+var suffixNames = []string {
+        "u8", "u16", "u32", "u64",
+        "i8", "i16", "i32", "i64",
+        "f32", "f64",
+}
+
+var radixNames = map[int] string {
+        2:  "binary",
+        8:  "octal",
+        10: "decimal",
+        16: "hexadecimal",
+}
+
+/* tokenizeNumber tokenizes a number literal. Numbers can be written in decimal,
+ * hexadecimal (0x), octal (0o), or binary (0b), and underscores can be put
+ * between digits to make them easier to read. Decimal and hexadecimal numbers
+ * can have a fraction and an exponent, which is written with e in decimal and p
+ * in hexadecimal, where it is a power of two. A number can end in a suffix such
+ * as u8, i32, or f64 that says what type it is.
+ */
+func (lexer *Lexer) tokenizeNumber (negative bool) {
+        line := lexer.line
+        start := line.index
+
+        token := Token { Column: line.index + line.Column }
+        if negative { token.Column -- }
+
+        radix := 10
+        if line.ch() == '0' {
+                switch line.peek() {
+                case 'x', 'X': radix = 16
+                case 'o', 'O': radix = 8
+                case 'b', 'B': radix = 2
+                }
+
+                if radix != 10 {
+                        line.nextRune()
+                        line.nextRune()
+                }
+        }
+
+        // the whole part
+        whole := lexer.tokenizeDigits(radix)
+        if whole == "" {
+                lexer.printError (
+                        diagnostic.CodeBadNumber, start, line.index,
+                        "expected", radixNames[radix], "digits")
+        }
+
+        // the fraction, if there is one
+        isFloat  := false
+        fraction := ""
+        canFloat := radix == 10 || radix == 16
+        if canFloat && line.ch() == '.' && isDigit(line.peek(), radix) {
+                line.nextRune()
+                isFloat  = true
+                fraction = lexer.tokenizeDigits(radix)
+        }
+
+        // the exponent, if there is one
+        exponent := ""
+        ch := unicode.ToLower(line.ch())
+        if (radix == 10 && ch == 'e') || (radix == 16 && ch == 'p') {
+                exponentStart := line.index
+                line.nextRune()
+                if line.ch() == '+' || line.ch() == '-' {
+                        exponent += string(line.ch())
+                        line.nextRune()
+                }
+
+                digits := lexer.tokenizeDigits(10)
+                if digits == "" {
+                        lexer.printError (
+                                diagnostic.CodeBadNumber,
+                                exponentStart, line.index,
+                                "exponent has no digits")
+                        digits = "0"
+                }
+
+                isFloat   = true
+                exponent += digits
+        }
+
+        // numbers starting with zero used to be octal, so rather than quietly
+        // changing what they mean, don't allow them at all
+        if radix == 10 && !isFloat && len(whole) > 1 && whole[0] == '0' {
+                lexer.printError (
+                        diagnostic.CodeBadNumber, start, line.index,
+                        "number cannot start with 0, use 0o for octal")
+        }
+
+        // the suffix, if there is one
+        suffixStart := line.index
+        for line.notEnd() && validate.IsNameContinue(line.ch()) {
+                token.Suffix += string(line.ch())
+                line.nextRune()
+        }
+
+        token.StringValue = string(line.runes[start:line.index])
+
+        bits := 64
+        if token.Suffix != "" {
+                var known bool
+                bits, known = numberSuffixes[token.Suffix]
+                if !known {
+                        lexer.sink.Report (lexer.diagnose (
+                                diagnostic.SeverityError,
+                                diagnostic.CodeBadNumber,
+                                suffixStart, line.index,
+                                "unknown number suffix \"" + token.Suffix +
+                                "\"",
+                        ).WithNotes (
+                                suggest.DidYouMean (
+                                        token.Suffix, suffixNames...)...))
+                        token.Suffix = ""
+                        bits = 64
+                }
+        }
+
+        kind := rune(0)
+        if token.Suffix != "" { kind = rune(token.Suffix[0]) }
+
+        if isFloat && (kind == 'u' || kind == 'i') {
+                lexer.printError (
+                        diagnostic.CodeBadNumber, suffixStart, line.index,
+                        "a number with a fraction or exponent cannot have",
+                        "an integer suffix")
+                kind = 0
+        }
+
+        if isFloat || kind == 'f' {
+                lexer.finishFloat (
+                        &token, negative, radix, bits,
+                        whole, fraction, exponent)
+        } else {
+                lexer.finishInteger (
+                        &token, negative, radix, bits, kind, whole)
+        }
+
+        line.addExisting(&token)
+}
+
+/* finishFloat gives a float literal its value, making sure that it is not too
+ * big or too small to fit in the specified amount of bits. A number that is too
+ * big for 64 bits is given the biggest value that does fit, since infinity
+ * cannot be written as a literal. A number that is not zero is too small if it
+ * would be rounded to zero.
+ */
+func (lexer *Lexer) finishFloat (
+        token    *Token,
+        negative bool,
+        radix    int,
+        bits     int,
+        whole    string,
+        fraction string,
+        exponent string,
+) {
+        if whole == "" { whole = "0" }
+
+        text := whole
+        if fraction != "" { text += "." + fraction }
+        if radix == 16 {
+                // strconv needs hexadecimal floats to have an exponent
+                if exponent == "" { exponent = "0" }
+                text = "0x" + text + "p" + exponent
+        } else if exponent != "" {
+                text += "e" + exponent
+        }
+
+        value, err := strconv.ParseFloat(text, 64)
+        if negative { value *= -1 }
+
+        tooBig := err != nil
+        if math.IsInf(value, 0) {
+                value = math.Copysign(math.MaxFloat64, value)
+        }
+        if bits == 32 && math.Abs(value) > math.MaxFloat32 { tooBig = true }
+        if tooBig {
+                lexer.printLiteralError (
+                        token, diagnostic.CodeNumberOverflow,
+                        "number is too big to fit in", bits, "bits")
+        }
+
+        isZero   := strings.Trim(whole + fraction, "0") == ""
+        tooSmall :=
+                !isZero &&
+                (value == 0 || bits == 32 && float32(value) == 0)
+        if tooSmall {
+                lexer.printLiteralError (
+                        token, diagnostic.CodeNumberOverflow,
+                        "number is too small to fit in", bits,
+                        "bits, it would be 0")
+        }
+
+        token.Kind  = TokenKindFloat
+        token.Value = value
+}
+
+/* finishInteger gives an integer literal its value, making sure that it fits
+ * in the type given by its suffix. The kind is the first letter of the suffix,
+ * or zero if there is none.
+ */
+func (lexer *Lexer) finishInteger (
+        token    *Token,
+        negative bool,
+        radix    int,
+        bits     int,
+        kind     rune,
+        whole    string,
+) {
+        // invalid digits have already been reported, so leave them out
+        digits := ""
+        for _, ch := range whole {
+                if isDigit(ch, radix) { digits += string(ch) }
+        }
+        if digits == "" { digits = "0" }
+
+        value, err := strconv.ParseUint(digits, radix, 64)
+        tooBig := err != nil
+
+        if negative && kind == 'u' {
+                lexer.printLiteralError (
+                        token, diagnostic.CodeBadNumber,
+                        "a negative number cannot have an unsigned suffix")
+                kind = 'i'
+        }
+
+        if negative || kind == 'i' {
+                // signed numbers can go one further in the negative direction
+                limit := uint64(1) << (bits - 1)
+                if !negative { limit -- }
+                if value > limit { tooBig = true }
+
+                token.Kind  = TokenKindSignedInteger
+                token.Value = int64(value)
+                if negative { token.Value = -int64(value) }
+        } else {
+                if bits < 64 && value >= uint64(1) << bits { tooBig = true }
+
+                token.Kind  = TokenKindInteger
+                token.Value = value
+        }
+
+        if tooBig {
+                description := "number is too big to fit in 64 bits"
+                if token.Suffix != "" {
+                        description = "number does not fit in " + token.Suffix
+                }
+                lexer.printLiteralError (
+                        token, diagnostic.CodeNumberOverflow, description)
+        }
+}
+
+/* printLiteralError reports a mistake with a number literal as a whole. The
+ * diagnostic spans the entire literal, including its sign and suffix, which
+ * must have already been read.
+ */
+func (lexer *Lexer) printLiteralError (
+        token *Token,
+        code  string,
+        cause ...interface {},
+) {
+        line := lexer.line
+        lexer.printError (
+                code, token.Column - line.Column, line.index, cause...)
+}
+
+/* tokenizeDigits reads digits in the specified radix, skipping over underscores
+ * that separate them. Digits that are too big for the radix are reported, but
+ * read anyway.
+ */
+func (lexer *Lexer) tokenizeDigits (radix int) (digits string) {
+        line := lexer.line
+
+        for line.notEnd() {
+                ch := line.ch()
+
+                if ch == '_' {
+                        if !isReadable(line.peek(), radix) || digits == "" {
+                                lexer.printError (
+                                        diagnostic.CodeBadNumber,
+                                        line.index, line.index + 1,
+                                        "underscores must be between digits")
+                        }
+                        line.nextRune()
+                        continue
+                }
+
+                if !isReadable(ch, radix) { break }
+
+                if !isDigit(ch, radix) {
+                        lexer.printError (
+                                diagnostic.CodeBadDigit,
+                                line.index, line.index + 1,
+                                "digit", string(ch), "is not valid in",
+                                radixNames[radix], "numbers")
+                }
+
+                digits += string(ch)
+                line.nextRune()
+        }
+
+        return
+}
+
+/* isReadable returns whether a rune should be read as part of a number in the
+ * specified radix. Every radix below hexadecimal still reads all decimal digits,
+ * so that stray ones can be reported.
+ */
+func isReadable (ch rune, radix int) (readable bool) {
+        if radix < 10 { radix = 10 }
+        return isDigit(ch, radix)
+}
+
+/* isDigit returns whether a rune is a digit in the specified radix.
+ */
+func isDigit (ch rune, radix int) (is bool) {
+        ch = unicode.ToLower(ch)
+        switch {
+        case ch >= '0' && ch <= '9': return int(ch - '0') < radix
+        case ch >= 'a' && ch <= 'f': return int(ch - 'a') + 10 < radix
+        }
+        return false
+}
+
+/* tokenizeForeignNumber tokenizes a number written with digits from a script
+ * other than Latin. Digits like these are fine in names, but not in number
+ * literals. The number is still tokenized, so that nothing else goes wrong
+ * because of it.
+ */
+func (lexer *Lexer) tokenizeForeignNumber () {
+        line := lexer.line
+        start := line.index
+
+        token := Token {
+                Kind:   TokenKindInteger,
+                Column: line.index + line.Column,
+        }
+
+        var value uint64
+        for line.notEnd() && unicode.IsDigit(line.ch()) {
+                token.StringValue += string(line.ch())
+                value = value * 10 + uint64(digitValue(line.ch()))
+                line.nextRune()
+        }
+
+        lexer.printError (
+                diagnostic.CodeBadDigit, start, line.index,
+                "number literals must be written with the digits 0 to 9")
+
+        token.Value = value
+        line.addExisting(&token)
+}
+
+/* digitValue returns the value of a decimal digit from any script. Unicode
+ * guarantees that these are always in runs of ten, from zero to nine.
+ */
+func digitValue (ch rune) (value int) {
+        zero := ch
+        for unicode.IsDigit(zero - 1) { zero -- }
+        return int(ch - zero) % 10
+}
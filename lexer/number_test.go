@@ -0,0 +1,91 @@
+package lexer
+
+import "testing"
+import "github.com/sashakoshka/arf/lineFile"
+import "github.com/sashakoshka/arf/diagnostic"
+
+func TestNegativeLiteralSpan (test *testing.T) {
+        cases := map[string] string {
+                "-129i8": diagnostic.CodeNumberOverflow,
+                "-1u8":   diagnostic.CodeBadNumber,
+        }
+
+        for literal, code := range cases {
+                source := ":arf\nmodule number\n---\ndata wr x:Int " + literal
+                file, err := lineFile.FromBytes (
+                        "number.arf", "number", []byte(source))
+                if err != nil { test.Fatal(err) }
+
+                collector := &diagnostic.Collector { }
+                Tokenize(file, collector)
+
+                diagnostics := collector.GetDiagnostics()
+                if len(diagnostics) != 1 {
+                        test.Errorf (
+                                "%s: expected 1 diagnostic, got %d",
+                                literal, len(diagnostics))
+                        continue
+                }
+
+                mistake := diagnostics[0]
+                column  := len("data wr x:Int ")
+                if mistake.Code != code {
+                        test.Errorf("%s: wrong code %s", literal, mistake.Code)
+                }
+                if mistake.Column != column {
+                        test.Errorf (
+                                "%s: starts at column %d, expected %d",
+                                literal, mistake.Column, column)
+                }
+                if mistake.EndColumn != column + len(literal) {
+                        test.Errorf (
+                                "%s: ends at column %d, expected %d",
+                                literal, mistake.EndColumn,
+                                column + len(literal))
+                }
+        }
+}
+
+func TestLexerDiagnosticSpans (test *testing.T) {
+        cases := []struct {
+                value     string
+                column    int
+                endColumn int
+        } {
+                { "0b102",     4, 5 },
+                { "0755",      0, 4 },
+                { "5u7",       1, 3 },
+                { "\"a\\qb\"", 2, 4 },
+                { "'ab'",      0, 4 },
+        }
+
+        for _, testCase := range cases {
+                prefix := "data wr x:Int "
+                source := ":arf\nmodule spans\n---\n" + prefix + testCase.value
+                file, err := lineFile.FromBytes (
+                        "spans.arf", "spans", []byte(source))
+                if err != nil { test.Fatal(err) }
+
+                collector := &diagnostic.Collector { }
+                Tokenize(file, collector)
+
+                diagnostics := collector.GetDiagnostics()
+                if len(diagnostics) != 1 {
+                        test.Errorf (
+                                "%s: expected 1 diagnostic, got %d",
+                                testCase.value, len(diagnostics))
+                        continue
+                }
+
+                mistake   := diagnostics[0]
+                column    := len(prefix) + testCase.column
+                endColumn := len(prefix) + testCase.endColumn
+                if mistake.Column != column || mistake.EndColumn != endColumn {
+                        test.Errorf (
+                                "%s: spans %d to %d, expected %d to %d",
+                                testCase.value,
+                                mistake.Column, mistake.EndColumn,
+                                column, endColumn)
+                }
+        }
+}
@@ -0,0 +1,172 @@
+package lexer
+
+import "strings"
+import "github.com/sashakoshka/arf/suggest"
+import "github.com/sashakoshka/arf/lineFile"
+import "github.com/sashakoshka/arf/diagnostic"
+
+const (
+        directiveAllow     = "arf:allow"
+        directiveAllowFile = "arf:allow-file"
+)
+
+/* ScanSuppressions searches a file for comments that allow categories of
+ * warnings. A comment of the form
+ *
+ *   # arf:allow category...
+ *
+ * allows warnings in the next section that starts after it, and a comment of
+ * the form
+ *
+ *   # arf:allow-file category...
+ *
+ * allows warnings in the entire file. Unknown categories are reported to sink.
+ */
+func ScanSuppressions (
+        file *lineFile.LineFile,
+        sink diagnostic.Sink,
+) (
+        suppressions []diagnostic.Suppression,
+) {
+        known := diagnostic.GetCategories()
+        
+        for row := 0; row < file.GetLength(); row ++ {
+                lineValue := file.GetLine(row)
+                trimmed := strings.TrimSpace(lineValue)
+
+                // comments inside of multi-line strings are just text
+                if opensMultiLineString(trimmed) {
+                        row = findStringEnd(file, row)
+                        continue
+                }
+                if !strings.HasPrefix(trimmed, "#") { continue }
+
+                fields := strings.Fields(strings.TrimPrefix(trimmed, "#"))
+                if len(fields) < 1 { continue }
+                if fields[0] != directiveAllow &&
+                        fields[0] != directiveAllowFile { continue }
+
+                suppression := diagnostic.Suppression { File: file }
+                for _, category := range fields[1:] {
+                        if !contains(known, category) {
+                                column := strings.Index(lineValue, category)
+                                sink.Report (diagnostic.At (
+                                        diagnostic.SeverityWarning,
+                                        diagnostic.CodeUnknownCategory,
+                                        file, row, column,
+                                        "unknown warning category \"" +
+                                        category + "\"",
+                                ).WithNotes (
+                                        suggest.DidYouMean (
+                                                category, known...)...))
+                                continue
+                        }
+                        
+                        suppression.Categories = append (
+                                suppression.Categories,
+                                category)
+                }
+
+                if fields[0] == directiveAllowFile {
+                        suppression.StartRow = 0
+                        suppression.EndRow   = file.GetLength() - 1
+                } else {
+                        suppression.StartRow,
+                        suppression.EndRow = findNextSection(file, row)
+                }
+
+                suppressions = append(suppressions, suppression)
+        }
+
+        return
+}
+
+/* opensMultiLineString returns whether a line, with the space around it
+ * trimmed off, ends with the start of a multi-line string.
+ */
+func opensMultiLineString (trimmed string) (opens bool) {
+        runes := []rune(trimmed)
+        for index := 0; index < len(runes); index ++ {
+                quote := runes[index]
+                if quote == '#' { return false }
+                if quote != '"' && quote != '`' && quote != '\'' { continue }
+
+                rest := string(runes[index + 1:])
+                if quote != '\'' && rest == string([]rune { quote, quote }) {
+                        return true
+                }
+
+                // skip over the rest of the string
+                for index ++; index < len(runes); index ++ {
+                        if runes[index] == quote { break }
+                        if runes[index] == '\\' && quote != '`' { index ++ }
+                }
+        }
+        return false
+}
+
+/* findStringEnd returns the last row of the multi-line string that starts at
+ * the end of the specified row. The string is made up of the lines after it
+ * that are indented further than it.
+ */
+func findStringEnd (file *lineFile.LineFile, row int) (endRow int) {
+        startIndent := getIndent(file.GetLine(row))
+        endRow = row
+        
+        for row ++; row < file.GetLength(); row ++ {
+                lineValue := file.GetLine(row)
+                if strings.TrimSpace(lineValue) == "" { continue }
+                if getIndent(lineValue) <= startIndent { break }
+                endRow = row
+        }
+        return
+}
+
+/* getIndent returns how many spaces a line starts with.
+ */
+func getIndent (lineValue string) (indent int) {
+        return len(lineValue) - len(strings.TrimLeft(lineValue, " "))
+}
+
+/* findNextSection returns the first and last row of the first section that
+ * starts after the specified row. A section starts at a line that has no
+ * indentation, and continues until the next one.
+ */
+func findNextSection (
+        file *lineFile.LineFile,
+        row  int,
+) (
+        startRow int,
+        endRow   int,
+) {
+        startRow = file.GetLength()
+        endRow   = file.GetLength() - 1
+        
+        for row ++; row < file.GetLength(); row ++ {
+                if !startsSection(file.GetLine(row)) { continue }
+                if startRow == file.GetLength() {
+                        startRow = row
+                } else {
+                        endRow = row - 1
+                        break
+                }
+        }
+        
+        return
+}
+
+/* startsSection returns whether or not a line starts a new section. Comments
+ * and empty lines do not.
+ */
+func startsSection (lineValue string) (starts bool) {
+        if len(lineValue) == 0 { return false }
+        if lineValue[0] == ' ' || lineValue[0] == '#' { return false }
+        return true
+}
+
+func contains (list []string, item string) (found bool) {
+        for _, element := range list {
+                if element == item { return true }
+        }
+        return false
+}
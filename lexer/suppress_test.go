@@ -0,0 +1,58 @@
+package lexer
+
+import "testing"
+import "github.com/sashakoshka/arf/lineFile"
+import "github.com/sashakoshka/arf/diagnostic"
+
+func TestScanSuppressionsSkipsStrings (test *testing.T) {
+        source :=
+`:arf
+---
+
+data ro text:String """
+        # arf:allow nope
+
+        # arf:allow confusable
+# arf:allow-file confusable
+data ro other:Int 5
+`
+        file, err := lineFile.FromBytes("test.arf", "test", []byte(source))
+        if err != nil { test.Fatal(err) }
+
+        collector := &diagnostic.Collector { }
+        suppressions := ScanSuppressions(file, collector)
+
+        if len(collector.GetDiagnostics()) > 0 {
+                test.Error (
+                        "directive inside of string was reported:",
+                        collector.GetDiagnostics()[0].Message)
+        }
+        if len(suppressions) != 1 {
+                test.Fatal (
+                        "expected 1 suppression, got", len(suppressions))
+        }
+        if suppressions[0].StartRow != 0 {
+                test.Error("wrong suppression was kept")
+        }
+}
+
+func TestOpensMultiLineString (test *testing.T) {
+        cases := map[string] bool {
+                `"""`:                     true,
+                "data ro text:String ```": true,
+                `""`:                      false,
+                `'''`:                     false,
+                `"# """`:                  false,
+                `# """`:                   false,
+                `call "a\"" """`:          true,
+        }
+
+        for line, expected := range cases {
+                opens := opensMultiLineString(line)
+                if opens != expected {
+                        test.Errorf (
+                                "%q: got %v, expected %v",
+                                line, opens, expected)
+                }
+        }
+}
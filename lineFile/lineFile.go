@@ -1,98 +1,139 @@
 package lineFile
 
 import (
+        "io"
         "os"
-        "fmt"
-        "bufio"
+        "bytes"
         "strings"
-        "strconv"
+        "io/ioutil"
 )
 
+/* LineFile holds the contents of a source file, split up into lines. It does
+ * not need to come from an actual file: it can be read from anything.
+ */
 type LineFile struct {
-        file   *os.File
-        path   string
-        module string
-        lines  []string
+        path    string
+        module  string
+        lines   []string
+
+        // offsets holds the byte offset of the start of each line.
+        offsets []int
 }
 
+/* Open reads the file at path. If path is "-", standard input is read instead.
+ */
 func Open (path string, moduleName string) (lineFile *LineFile, err error) {
+        if path == "-" { return Read(path, moduleName, os.Stdin) }
+        
+        file, err := os.Open(path)
+        if err != nil {
+                return &LineFile { module: moduleName, path: path }, err
+        }
+        defer file.Close()
+
+        return Read(path, moduleName, file)
+}
+
+/* Read reads a file from reader. Path is only used to refer to the file in
+ * diagnostics, so it does not need to exist.
+ */
+func Read (
+        path       string,
+        moduleName string,
+        reader     io.Reader,
+) (
+        lineFile *LineFile,
+        err error,
+) {
         lineFile = &LineFile {
                 module: moduleName,
                 path:   path,
         }
-        
-        lineFile.file, err = os.Open(path)
-        defer lineFile.file.Close()
 
-        scanner := bufio.NewScanner(lineFile.file)
-        for scanner.Scan() {
-                lineFile.lines = append(lineFile.lines, scanner.Text())
-        }
+        content, err := ioutil.ReadAll(reader)
+        if err != nil { return }
 
-        err = scanner.Err()
+        lineFile.split(content)
         return
 }
 
-func (lineFile *LineFile) GetLine (row int) (line string) {
-        return lineFile.lines[row]
-}
+/* split breaks content up into the lines of the file. A file never changes
+ * once it has been read, so that everything that refers to it always sees the
+ * same content. An edited file is read again as a new file.
+ */
+func (lineFile *LineFile) split (content []byte) {
+        lineFile.lines   = nil
+        lineFile.offsets = nil
+        
+        offset := 0
+        for len(content) > 0 {
+                end := bytes.IndexByte(content, '\n')
+                next := end + 1
+                if end < 0 {
+                        end  = len(content)
+                        next = len(content)
+                }
 
-func (lineFile *LineFile) GetLength () (length int) {
-        return len(lineFile.lines)
+                line := strings.TrimSuffix(string(content[:end]), "\r")
+                lineFile.lines   = append(lineFile.lines, line)
+                lineFile.offsets = append(lineFile.offsets, offset)
+
+                offset += next
+                content = content[next:]
+        }
 }
 
-func (lineFile *LineFile) PrintWarning (
-        column int,
-        row int,
-        cause ...interface {},
+/* FromBytes creates a file out of content that is already in memory, such as an
+ * unsaved editor buffer.
+ */
+func FromBytes (
+        path       string,
+        moduleName string,
+        content    []byte,
+) (
+        lineFile *LineFile,
+        err error,
 ) {
-        lineFile.printMistake("\033[33m!!!\033[0m", column, row, cause...)
+        return Read(path, moduleName, bytes.NewReader(content))
 }
 
-func (lineFile *LineFile) PrintError (
-        column int,
-        row int,
-        cause ...interface {},
-) {
-        lineFile.printMistake("\033[31mERR\033[0m", column, row, cause...)
+func (lineFile *LineFile) GetLine (row int) (line string) {
+        return lineFile.lines[row]
 }
 
-func (lineFile *LineFile) PrintFatal (
-        cause ...interface {},
-) {
-        fmt.Println ("\033[31mXXX\033[0m", "\033[90min\033[0m", lineFile.path,
-                "\033[90mof\033[0m", lineFile.module)
-        fmt.Print("    ")
-        fmt.Println(cause...)
+func (lineFile *LineFile) GetLength () (length int) {
+        return len(lineFile.lines)
 }
 
-func (lineFile *LineFile) printMistake (
-        kind string,
-        column int,
-        row int,
-        cause ...interface{},
-) {
-        indent := 0
-        lineValue := lineFile.lines[row]
-        for i, ch := range lineValue {
-                indent = i
-                if ch != ' ' { break }
+/* GetOffset converts a row and column into a byte offset from the start of the
+ * file. Columns count runes, not bytes. Positions past the end of a line or
+ * the file are clamped to the end of it.
+ */
+func (lineFile *LineFile) GetOffset (row int, column int) (offset int) {
+        if row < 0 { return 0 }
+        if row >= len(lineFile.lines) {
+                if len(lineFile.lines) == 0 { return 0 }
+                row = len(lineFile.lines) - 1
+                column = len(lineFile.lines[row])
         }
         
-        fmt.Println (
-                kind, "\033[90min\033[0m", lineFile.path,
-                "\033[34m" + strconv.Itoa(row + 1) + ":" +
-                strconv.Itoa(column + 1),
-                "\033[90mof\033[0m", lineFile.module)
-        fmt.Println("   ", strings.TrimSpace(lineValue))
-
-        fmt.Print("    ")
-        for column > indent {
-                fmt.Print("-")
+        offset = lineFile.offsets[row]
+        for index := range lineFile.lines[row] {
+                if column <= 0 { return offset + index }
                 column --
         }
-        fmt.Println("^")
         
-        fmt.Print("    ")
-        fmt.Println(cause...)
+        return offset + len(lineFile.lines[row])
+}
+
+/* GetPath returns the path of the file, as it was passed to Open.
+ */
+func (lineFile *LineFile) GetPath () (path string) {
+        return lineFile.path
+}
+
+/* GetModule returns the name of the module the file belongs to.
+ */
+func (lineFile *LineFile) GetModule () (module string) {
+        return lineFile.module
 }
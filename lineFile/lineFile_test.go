@@ -0,0 +1,39 @@
+package lineFile
+
+import "strings"
+import "testing"
+
+func TestRead (test *testing.T) {
+        reader := strings.NewReader("first\r\nsecond\n\nfourth")
+        file, err := Read("buffer", "module", reader)
+        if err != nil { test.Fatal(err) }
+
+        if file.GetPath()   != "buffer" { test.Error("wrong path")   }
+        if file.GetModule() != "module" { test.Error("wrong module") }
+
+        expected := []string { "first", "second", "", "fourth" }
+        if file.GetLength() != len(expected) {
+                test.Fatal("expected", len(expected), "lines, got",
+                        file.GetLength())
+        }
+        for row, line := range expected {
+                if file.GetLine(row) != line {
+                        test.Errorf (
+                                "line %d is %q, expected %q",
+                                row, file.GetLine(row), line)
+                }
+        }
+
+        if file.GetOffset(1, 0) != len("first\r\n") {
+                test.Error("wrong offset for line 1:", file.GetOffset(1, 0))
+        }
+}
+
+func TestFromBytes (test *testing.T) {
+        file, err := FromBytes("buffer", "module", []byte("a\nb\n"))
+        if err != nil { test.Fatal(err) }
+        if file.GetLength() != 2 {
+                test.Error("wrong number of lines", file.GetLength())
+        }
+        if file.GetLine(1) != "b" { test.Error("wrong second line") }
+}
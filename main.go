@@ -1,32 +1,430 @@
 package main
 
+import "io"
 import "os"
 import "fmt"
+import "flag"
+import "path"
+import "errors"
+import "strings"
+import "github.com/sashakoshka/arf/lexer"
+import "github.com/sashakoshka/arf/suggest"
 import "github.com/sashakoshka/arf/parser"
 import "github.com/sashakoshka/arf/analyzer"
+import "github.com/sashakoshka/arf/lineFile"
+import "github.com/sashakoshka/arf/diagnostic"
+
+/* These are the exit codes that arf uses. Warnings alone do not cause a non-
+ * zero exit code.
+ */
+const (
+        exitSuccess = 0
+        exitErrors  = 1
+        exitUsage   = 2
+)
+
+/* Command is a subcommand of the arf command line interface.
+ */
+type Command struct {
+        name        string
+        arguments   string
+        description string
+        run         func (flags *flag.FlagSet) (code int)
+
+        // optional is true if the command can be run without an argument.
+        optional bool
+}
+
+var commands = []Command {
+        {
+                name:        "check",
+                arguments:   "<module path>",
+                description: "parse and analyze a module, reporting problems",
+                run:         runCheck,
+        }, {
+                name:        "dump",
+                arguments:   "<module path>",
+                description: "print the parsed AST of a module",
+                run:         runDump,
+        }, {
+                name:        "tokens",
+                arguments:   "<file>",
+                description: "print the tokens that the lexer produces",
+                run:         runTokens,
+        }, {
+                name:        "build",
+                arguments:   "<module path>",
+                description: "compile a module into an executable",
+                run:         runBuild,
+        }, {
+                name:        "run",
+                arguments:   "<module path>",
+                description: "compile a module and execute it",
+                run:         runRun,
+        }, {
+                name:        "explain",
+                arguments:   "[code]",
+                description: "explain what a diagnostic code means",
+                run:         runExplain,
+                optional:    true,
+        },
+}
+
+var errNoCodeGen = errors.New("code generation is not implemented yet")
+
+var (
+        verbose   bool
+        color     string
+        format    string
+        maxErrors int
+)
+
+/* output is where diagnostics, and the summary printed after them, are written.
+ */
+var output io.Writer = os.Stderr
+
+/* sarif holds on to diagnostics until they can be written out as a SARIF log.
+ * It is only used when diagnostics are being output in SARIF format.
+ */
+var sarif *diagnostic.SARIFWriter
+
+/* mistakes counts all diagnostics that are reported, and passes them on to be
+ * printed.
+ */
+var mistakes = &diagnostic.Counter { }
+
+/* policy decides which diagnostics are reported, according to the -W flags and
+ * -max-errors. Everything should report diagnostics here.
+ */
+var policy = &diagnostic.Policy { Sink: mistakes }
 
 func main () {
-        if (len(os.Args) < 2) {
-                fmt.Println("specify module path")
-                os.Exit(1)
+        global := flag.NewFlagSet("arf", flag.ContinueOnError)
+        global.BoolVar(&verbose, "v", false, "print progress information")
+        global.StringVar (
+                &color, "color", "auto",
+                "whether to color diagnostics: auto, always, or never")
+        global.StringVar (
+                &format, "diagnostics", "text",
+                "how to output diagnostics: text, json, or sarif")
+        global.IntVar (
+                &maxErrors, "max-errors", 0,
+                "stop reporting diagnostics after this many errors, or 0 " +
+                "for no limit")
+        global.Usage = func () { printUsage(global) }
+
+        arguments, err := parseWarningFlags(global, os.Args[1:])
+        if err != nil {
+                fmt.Fprintln(os.Stderr, err)
+                os.Exit(exitUsage)
         }
+        
+        err = global.Parse(arguments)
+        if err == flag.ErrHelp { os.Exit(exitSuccess) }
+        if err != nil          { os.Exit(exitUsage)   }
+        policy.MaxErrors = maxErrors
 
-        var totalWarnings int
-        var totalErrors   int
+        renderer := &diagnostic.Renderer { Output: output }
         
-        module,
-        parserWarnings,
-        parserErrors,
-        err := parser.Parse(os.Args[1], false)
+        switch format {
+        case "text":
+                mistakes.Sink = renderer
+        case "json":
+                mistakes.Sink = &diagnostic.JSONWriter { Output: output }
+        case "sarif":
+                sarif = &diagnostic.SARIFWriter { Output: output }
+                mistakes.Sink = sarif
+        default:
+                fmt.Fprintln (
+                        os.Stderr, "invalid value \"" + format + "\"",
+                        "for -diagnostics, use text, json, or sarif")
+                os.Exit(exitUsage)
+        }
+
+        switch color {
+        case "auto":   renderer.Color = diagnostic.DetectColor(os.Stderr)
+        case "always": renderer.Color = true
+        case "never":  renderer.Color = false
+        default:
+                fmt.Fprintln (
+                        os.Stderr, "invalid value \"" + color + "\"",
+                        "for -color, use auto, always, or never")
+                os.Exit(exitUsage)
+        }
+
+        parser.Verbose = verbose
+
+        if global.NArg() < 1 {
+                printUsage(global)
+                os.Exit(exitUsage)
+        }
+
+        name := global.Arg(0)
+        if name == "help" {
+                printUsage(global)
+                os.Exit(exitSuccess)
+        }
+
+        for _, command := range commands {
+                if command.name != name { continue }
+
+                flags := flag.NewFlagSet (
+                        "arf " + command.name,
+                        flag.ContinueOnError)
+                flags.Usage = func () { printCommandUsage(command, flags) }
+
+                err = flags.Parse(global.Args()[1:])
+                if err == flag.ErrHelp { os.Exit(exitSuccess) }
+                if err != nil          { os.Exit(exitUsage)   }
+
+                wrongCount := flags.NArg() != 1
+                if command.optional { wrongCount = flags.NArg() > 1 }
+                if wrongCount {
+                        printCommandUsage(command, flags)
+                        os.Exit(exitUsage)
+                }
+
+                os.Exit(command.run(flags))
+        }
+
+        fmt.Fprintln(os.Stderr, "unknown command \"" + name + "\"")
+        printUsage(global)
+        os.Exit(exitUsage)
+}
+
+func printUsage (global *flag.FlagSet) {
+        output := global.Output()
+        fmt.Fprintln(output, "usage: arf [flags] <command> [arguments]")
+        fmt.Fprintln(output)
+        fmt.Fprintln(output, "commands:")
+        for _, command := range commands {
+                fmt.Fprintf(output, "  %-8s %s\n",
+                        command.name, command.description)
+        }
+        fmt.Fprintln(output)
+        fmt.Fprintln(output, "flags:")
+        global.PrintDefaults()
+        fmt.Fprintln(output, "  -W<category>")
+        fmt.Fprintln(output, "    \tturn on warnings in a category")
+        fmt.Fprintln(output, "  -Wno-<category>")
+        fmt.Fprintln(output, "    \tturn off warnings in a category")
+        fmt.Fprintln(output, "  -Werror")
+        fmt.Fprintln(output, "    \treport warnings as errors")
+        fmt.Fprintln(output)
+        fmt.Fprintln(output, "warning categories:")
+        for _, category := range diagnostic.GetCategories() {
+                if diagnostic.IsOnByDefault(category) {
+                        fmt.Fprintln(output, "  " + category)
+                } else {
+                        fmt.Fprintln(output, "  " + category, "(off by default)")
+                }
+        }
+        fmt.Fprintln(output)
+        fmt.Fprintln(output, "exit codes:")
+        fmt.Fprintln(output, "  0 success, even if there were warnings")
+        fmt.Fprintln(output, "  1 errors were found")
+        fmt.Fprintln(output, "  2 the command line was invalid")
+        fmt.Fprintln(output)
+        fmt.Fprintln (
+                output,
+                "run \"arf <command> -help\" for help with a command")
+}
+
+func printCommandUsage (command Command, flags *flag.FlagSet) {
+        output := flags.Output()
+        fmt.Fprintln (
+                output, "usage: arf", command.name,
+                "[flags]", command.arguments)
+        fmt.Fprintln(output)
+        fmt.Fprintln(output, command.description)
+
+        hasFlags := false
+        flags.VisitAll(func (*flag.Flag) { hasFlags = true })
+        if !hasFlags { return }
+
+        fmt.Fprintln(output)
+        fmt.Fprintln(output, "flags:")
+        flags.PrintDefaults()
+}
+
+/* parseWarningFlags applies the -W flags in the arguments to the diagnostic
+ * policy, and returns the rest of the arguments. These cannot be parsed with
+ * the flag package, because the part after -W can be anything. Only global
+ * flags are looked at, so parsing stops at the command name.
+ */
+func parseWarningFlags (
+        global    *flag.FlagSet,
+        arguments []string,
+) (
+        remaining []string,
+        err       error,
+) {
+        categories := diagnostic.GetCategories()
         
-        totalWarnings += parserWarnings
-        totalErrors   += parserErrors
-        if err != nil { os.Exit(1) }
-        module.Dump()
+        for index := 0; index < len(arguments); index ++ {
+                argument := arguments[index]
+                if argument == "--" || !strings.HasPrefix(argument, "-") ||
+                        argument == "-" {
+                        
+                        remaining = append(remaining, arguments[index:]...)
+                        break
+                }
+                
+                if !strings.HasPrefix(argument, "-W") {
+                        remaining = append(remaining, argument)
+                        if takesValue(global, argument) &&
+                                index + 1 < len(arguments) {
+                                
+                                index ++
+                                remaining = append (
+                                        remaining, arguments[index])
+                        }
+                        continue
+                }
+
+                setting := strings.TrimPrefix(argument, "-W")
+                enable := !strings.HasPrefix(setting, "no-")
+                category := strings.TrimPrefix(setting, "no-")
+
+                if setting == "error" {
+                        policy.WarningsAsErrors = true
+                        continue
+                }
 
-        analyzerWarnings, analyzerErrors, err := analyzer.Analyze(module)
-        totalWarnings += analyzerWarnings
-        totalErrors   += analyzerErrors
+                if !contains(categories, category) {
+                        description := "unknown warning category \"" +
+                                category + "\""
+                        closest, found := suggest.Closest (
+                                category, categories...)
+                        if found {
+                                description += ", did you mean \"" +
+                                        closest + "\"?"
+                        }
+                        return nil, errors.New(description)
+                }
+
+                if enable {
+                        policy.Enable(category)
+                } else {
+                        policy.Disable(category)
+                }
+        }
+
+        return
+}
+
+/* takesValue returns whether a flag is given its value in the argument after
+ * it, like "-color never".
+ */
+func takesValue (flags *flag.FlagSet, argument string) (takes bool) {
+        name := strings.TrimLeft(argument, "-")
+        if strings.Contains(name, "=") { return false }
+
+        found := flags.Lookup(name)
+        if found == nil { return false }
         
-        fmt.Println("(i)", totalWarnings, "warnings and", totalErrors, "errors")
+        boolean, isBoolean := found.Value.(interface { IsBoolFlag () bool })
+        return !(isBoolean && boolean.IsBoolFlag())
+}
+
+func contains (list []string, item string) (found bool) {
+        for _, element := range list {
+                if element == item { return true }
+        }
+        return false
+}
+
+/* finish prints out a summary of the mistakes that were found, and returns the
+ * exit code that arf should exit with.
+ */
+func finish () (code int) {
+        if sarif != nil { sarif.Flush() }
+        
+        textual   := format == "text"
+        anyErrors := mistakes.Warnings > 0 || mistakes.Errors > 0
+        if textual && (verbose || anyErrors) {
+                fmt.Fprintln (
+                        output, "(i)", mistakes.Warnings, "warnings and",
+                        mistakes.Errors, "errors")
+        }
+
+        if mistakes.Errors > 0 { return exitErrors }
+        return exitSuccess
+}
+
+func runCheck (flags *flag.FlagSet) (code int) {
+        module, err := parser.Parse(flags.Arg(0), false, policy)
+        if err != nil { return finish() }
+
+        analyzer.Analyze(module, policy)
+        return finish()
+}
+
+func runDump (flags *flag.FlagSet) (code int) {
+        module, err := parser.Parse(flags.Arg(0), false, policy)
+        if err != nil { return finish() }
+
+        module.Dump()
+        return finish()
+}
+
+func runTokens (flags *flag.FlagSet) (code int) {
+        filePath := flags.Arg(0)
+        moduleName := parser.GetModuleName(filePath)
+        if moduleName == "" { moduleName = path.Base(filePath) }
+
+        file, err := lineFile.Open(filePath, moduleName)
+        if err != nil {
+                policy.Report (diagnostic.InModule (
+                        diagnostic.SeverityFatal, diagnostic.CodeUnreadable,
+                        moduleName, err))
+                return finish()
+        }
+
+        lines, err := lexer.Tokenize(file, policy)
+        if err != nil { return finish() }
+
+        for _, line := range lines {
+                line.Dump()
+        }
+
+        return finish()
+}
+
+func runBuild (flags *flag.FlagSet) (code int) {
+        module, err := parser.Parse(flags.Arg(0), false, policy)
+        if err != nil { return finish() }
+
+        analyzer.Analyze(module, policy)
+        if mistakes.Errors > 0 { return finish() }
+
+        name, _, _, _ := module.GetMetadata()
+        policy.Report (diagnostic.InModule (
+                diagnostic.SeverityFatal, diagnostic.CodeNoCodeGen,
+                name, errNoCodeGen))
+        return finish()
+}
+
+func runRun (flags *flag.FlagSet) (code int) {
+        return runBuild(flags)
+}
+
+func runExplain (flags *flag.FlagSet) (code int) {
+        if flags.NArg() == 0 {
+                for _, code := range diagnostic.GetCodes() {
+                        fmt.Println(code, diagnostic.GetSummary(code))
+                }
+                return exitSuccess
+        }
+
+        explanation, exists := diagnostic.Explain(flags.Arg(0))
+        if !exists {
+                fmt.Fprintln (
+                        os.Stderr, "there is no diagnostic with the code",
+                        flags.Arg(0))
+                return exitUsage
+        }
+
+        fmt.Print(strings.ToUpper(flags.Arg(0)), ": ", explanation)
+        return exitSuccess
 }
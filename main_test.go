@@ -0,0 +1,93 @@
+package main
+
+import "os"
+import "flag"
+import "testing"
+import "strings"
+import "github.com/sashakoshka/arf/diagnostic"
+
+func TestParseWarningFlags (test *testing.T) {
+        cases := []struct {
+                arguments string
+                remaining string
+                asErrors  bool
+        } {
+                { "-Werror check x",        "check x",               true  },
+                { "-v -Werror check x",     "-v check x",            true  },
+                { "-color never -Werror x", "-color never x",        true  },
+                { "-color=never -Werror x", "-color=never x",        true  },
+                { "check -Werror x",        "check -Werror x",       false },
+                { "-color -Werror x",       "-color -Werror x",      false },
+                { "-- -Werror x",           "-- -Werror x",          false },
+        }
+
+        for _, testCase := range cases {
+                policy = &diagnostic.Policy { }
+                global := flag.NewFlagSet("arf", flag.ContinueOnError)
+                global.Bool("v", false, "")
+                global.String("color", "auto", "")
+
+                remaining, err := parseWarningFlags (
+                        global, strings.Fields(testCase.arguments))
+                if err != nil {
+                        test.Errorf("%s: %v", testCase.arguments, err)
+                        continue
+                }
+
+                got := strings.Join(remaining, " ")
+                if got != testCase.remaining {
+                        test.Errorf (
+                                "%s: remaining is %q, expected %q",
+                                testCase.arguments, got, testCase.remaining)
+                }
+                if policy.WarningsAsErrors != testCase.asErrors {
+                        test.Errorf (
+                                "%s: warnings as errors is %v, expected %v",
+                                testCase.arguments, policy.WarningsAsErrors,
+                                testCase.asErrors)
+                }
+        }
+}
+
+func TestParseWarningFlagsUnknown (test *testing.T) {
+        policy = &diagnostic.Policy { }
+        global := flag.NewFlagSet("arf", flag.ContinueOnError)
+        
+        _, err := parseWarningFlags(global, []string { "-Wnope", "check" })
+        if err == nil {
+                test.Error("unknown category was accepted")
+        }
+}
+
+/* TestExplain checks that arf explain works for every code.
+ */
+func TestExplain (test *testing.T) {
+        stdout, stderr := os.Stdout, os.Stderr
+        discard, err := os.Create(os.DevNull)
+        if err != nil { test.Fatal(err) }
+        os.Stdout, os.Stderr = discard, discard
+        defer func () {
+                os.Stdout, os.Stderr = stdout, stderr
+                discard.Close()
+        } ()
+
+        explain := func (arguments ...string) (code int) {
+                flags := flag.NewFlagSet("arf explain", flag.ContinueOnError)
+                flags.Parse(arguments)
+                return runExplain(flags)
+        }
+
+        codes := diagnostic.GetCodes()
+        if len(codes) == 0 { test.Fatal("there are no codes") }
+        for _, code := range codes {
+                if explain(code) != exitSuccess {
+                        test.Errorf("%s cannot be explained", code)
+                }
+        }
+        if explain() != exitSuccess {
+                test.Error("codes cannot be listed")
+        }
+        if explain("E9999") != exitUsage {
+                test.Error("E9999 was explained")
+        }
+}
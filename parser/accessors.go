@@ -0,0 +1,489 @@
+package parser
+
+/* Node is any part of the AST that came from somewhere in the source code. The
+ * nodes are *Module, *Function, *Typedef, *Data, *Variable, *Block, *Statement,
+ * *Argument, *Dereference, *Type, and *Identifier.
+ */
+type Node interface {
+        // GetPosition returns the span of source code that the node came
+        // from.
+        GetPosition () (where Position)
+}
+
+/* GetPosition returns the position of the module, which does not point to any
+ * particular file.
+ */
+func (module *Module) GetPosition () (where Position) {
+        return module.where
+}
+
+/* GetName returns the name of the module.
+ */
+func (module *Module) GetName () (name string) {
+        return module.name
+}
+
+/* GetFunction returns the function section with the specified name.
+ */
+func (module *Module) GetFunction (
+        name string,
+) (
+        function *Function,
+        exists bool,
+) {
+        function, exists = module.functions[name]
+        return
+}
+
+/* GetTypedef returns the type section with the specified name.
+ */
+func (module *Module) GetTypedef (
+        name string,
+) (
+        typedef *Typedef,
+        exists bool,
+) {
+        typedef, exists = module.typedefs[name]
+        return
+}
+
+/* GetData returns the data section with the specified name.
+ */
+func (module *Module) GetData (
+        name string,
+) (
+        data *Data,
+        exists bool,
+) {
+        data, exists = module.datas[name]
+        return
+}
+
+/* GetLeading returns the whole-line comments that come directly before
+ * whatever the comments are attached to.
+ */
+func (comments Comments) GetLeading () (leading []string) {
+        return comments.leading
+}
+
+/* GetTrailing returns the comment at the end of the line, or an empty string if
+ * there is none.
+ */
+func (comments Comments) GetTrailing () (trailing string) {
+        return comments.trailing
+}
+
+func (function *Function) GetPosition () (where Position) {
+        return function.where
+}
+
+func (function *Function) GetComments () (comments Comments) {
+        return function.comments
+}
+
+func (function *Function) GetName () (name string) {
+        return function.name
+}
+
+/* GetPermission returns what the module the function is in, and other modules,
+ * are allowed to do with it.
+ */
+func (function *Function) GetPermission () (internal Mode, external Mode) {
+        return function.modeInternal, function.modeExternal
+}
+
+/* IsMember returns whether the function is a method of a type, in which case it
+ * has a receiver.
+ */
+func (function *Function) IsMember () (isMember bool) {
+        return function.isMember
+}
+
+/* GetSelf returns the receiver of a member function, or nil if the function is
+ * not a member.
+ */
+func (function *Function) GetSelf () (self *Variable) {
+        if !function.isMember || function.root == nil { return nil }
+        return function.root.variables[function.self]
+}
+
+/* GetSelfType returns the name of the type that a member function belongs to.
+ */
+func (function *Function) GetSelfType () (selfType string) {
+        return function.selfType
+}
+
+/* GetInputs returns the input arguments of the function, in order.
+ */
+func (function *Function) GetInputs () (inputs []*Variable) {
+        return function.getVariables(function.inputs)
+}
+
+/* GetOutputs returns the output arguments of the function, in order.
+ */
+func (function *Function) GetOutputs () (outputs []*Variable) {
+        return function.getVariables(function.outputs)
+}
+
+func (function *Function) getVariables (
+        names []string,
+) (
+        variables []*Variable,
+) {
+        if function.root == nil { return }
+        for _, name := range names {
+                variables = append(variables, function.root.variables[name])
+        }
+        return
+}
+
+/* GetRoot returns the outermost block of the function. Its variables include
+ * the receiver and the arguments of the function.
+ */
+func (function *Function) GetRoot () (root *Block) {
+        return function.root
+}
+
+/* IsExternal returns whether the function is defined outside of arf, in which
+ * case its block is empty.
+ */
+func (function *Function) IsExternal () (external bool) {
+        return function.external
+}
+
+func (identifier *Identifier) GetPosition () (where Position) {
+        return identifier.where
+}
+
+/* GetTrail returns each of the dot separated names that make up the
+ * identifier.
+ */
+func (identifier *Identifier) GetTrail () (trail []string) {
+        return identifier.trail
+}
+
+func (what *Type) GetPosition () (where Position) {
+        return what.where
+}
+
+/* GetName returns the name of the type. If the type is a pointer, it has no
+ * name.
+ */
+func (what *Type) GetName () (name *Identifier) {
+        return &what.name
+}
+
+/* GetPoints returns the type that a pointer points to, or nil if the type is
+ * not a pointer.
+ */
+func (what *Type) GetPoints () (points *Type) {
+        return what.points
+}
+
+/* GetItems returns how many items a pointer points to. A pointer with no count
+ * has zero.
+ */
+func (what *Type) GetItems () (items uint64) {
+        return what.items
+}
+
+func (what *Type) IsPointer () (pointer bool) {
+        return what.points != nil
+}
+
+func (what *Type) IsMutable () (mutable bool) {
+        return what.mutable
+}
+
+/* GetBlock returns the block, or nil if the item is a statement.
+ */
+func (item *BlockOrStatement) GetBlock () (block *Block) {
+        return item.block
+}
+
+/* GetStatement returns the statement, or nil if the item is a block.
+ */
+func (item *BlockOrStatement) GetStatement () (statement *Statement) {
+        return item.statement
+}
+
+/* GetNode returns the block or statement, whichever the item is.
+ */
+func (item *BlockOrStatement) GetNode () (node Node) {
+        if item.block != nil { return item.block }
+        return item.statement
+}
+
+func (block *Block) GetPosition () (where Position) {
+        return block.where
+}
+
+/* GetVariables returns the variables declared in the block, by name.
+ */
+func (block *Block) GetVariables () (variables map[string] *Variable) {
+        return block.variables
+}
+
+/* GetVariable returns the variable declared in the block with the specified
+ * name. Variables in blocks outside of this one are not searched.
+ */
+func (block *Block) GetVariable (
+        name string,
+) (
+        variable *Variable,
+        exists   bool,
+) {
+        variable, exists = block.variables[name]
+        return
+}
+
+/* GetItems returns the statements and blocks inside the block, in order.
+ */
+func (block *Block) GetItems () (items []BlockOrStatement) {
+        return block.items
+}
+
+func (statement *Statement) GetPosition () (where Position) {
+        return statement.where
+}
+
+/* GetComments returns the comments attached to the statement. Only statements
+ * that start a line have comments.
+ */
+func (statement *Statement) GetComments () (comments Comments) {
+        return statement.comments
+}
+
+/* GetCommand returns the identifier of what the statement calls. External
+ * statements have no command.
+ */
+func (statement *Statement) GetCommand () (command *Identifier) {
+        return &statement.command
+}
+
+func (statement *Statement) GetArguments () (arguments []Argument) {
+        return statement.arguments
+}
+
+/* IsExternal returns whether the statement calls something outside of arf, in
+ * which case GetExternalCommand returns what it calls.
+ */
+func (statement *Statement) IsExternal () (external bool) {
+        return statement.external
+}
+
+func (statement *Statement) GetExternalCommand () (command string) {
+        return statement.externalCommand
+}
+
+/* GetReturnsTo returns the identifiers that the results of the statement are
+ * put into.
+ */
+func (statement *Statement) GetReturnsTo () (returnsTo []*Identifier) {
+        return statement.returnsTo
+}
+
+func (dereference *Dereference) GetPosition () (where Position) {
+        return dereference.where
+}
+
+/* GetDereferences returns the argument that is dereferenced.
+ */
+func (dereference *Dereference) GetDereferences () (argument *Argument) {
+        return dereference.dereferences
+}
+
+/* GetOffset returns how many items past the argument are dereferenced. This is
+ * not a byte offset into the file, unlike Position.GetOffset.
+ */
+func (dereference *Dereference) GetOffset () (offset uint64) {
+        return dereference.offset
+}
+
+/* ToString returns a description of the argument kind, for use in messages.
+ */
+func (kind ArgumentKind) ToString () (description string) {
+        switch kind {
+        case ArgumentKindStatement:     return "statement"
+        case ArgumentKindIdentifier:    return "identifier"
+        case ArgumentKindDereference:   return "dereference"
+        case ArgumentKindString:        return "string"
+        case ArgumentKindRune:          return "rune"
+        case ArgumentKindInteger:       return "integer"
+        case ArgumentKindSignedInteger: return "signed integer"
+        case ArgumentKindFloat:         return "float"
+        }
+        return "none"
+}
+
+func (argument *Argument) GetPosition () (where Position) {
+        return argument.where
+}
+
+func (argument *Argument) GetKind () (kind ArgumentKind) {
+        return argument.kind
+}
+
+/* GetValue returns the value of the argument, whatever kind it is. Statements,
+ * identifiers, and dereferences are returned as pointers.
+ */
+func (argument *Argument) GetValue () (value interface {}) {
+        switch argument.kind {
+        case ArgumentKindStatement:     return argument.statementValue
+        case ArgumentKindIdentifier:    return argument.identifierValue
+        case ArgumentKindDereference:   return argument.dereferenceValue
+        case ArgumentKindString:        return argument.stringValue
+        case ArgumentKindRune:          return argument.runeValue
+        case ArgumentKindInteger:       return argument.integerValue
+        case ArgumentKindSignedInteger: return argument.signedIntegerValue
+        case ArgumentKindFloat:         return argument.floatValue
+        }
+        return nil
+}
+
+func (argument *Argument) GetStatement () (statement *Statement) {
+        return argument.statementValue
+}
+
+func (argument *Argument) GetIdentifier () (identifier *Identifier) {
+        return argument.identifierValue
+}
+
+func (argument *Argument) GetDereference () (dereference *Dereference) {
+        return argument.dereferenceValue
+}
+
+func (argument *Argument) GetString () (value string) {
+        return argument.stringValue
+}
+
+func (argument *Argument) GetRune () (value rune) {
+        return argument.runeValue
+}
+
+func (argument *Argument) GetInteger () (value uint64) {
+        return argument.integerValue
+}
+
+func (argument *Argument) GetSignedInteger () (value int64) {
+        return argument.signedIntegerValue
+}
+
+func (argument *Argument) GetFloat () (value float64) {
+        return argument.floatValue
+}
+
+/* GetSuffix returns the type suffix that a number argument was written with,
+ * such as u8 or f32. If it had none, an empty string is returned.
+ */
+func (argument *Argument) GetSuffix () (suffix string) {
+        return argument.suffix
+}
+
+func (variable *Variable) GetPosition () (where Position) {
+        return variable.where
+}
+
+func (variable *Variable) GetComments () (comments Comments) {
+        return variable.comments
+}
+
+func (variable *Variable) GetName () (name string) {
+        return variable.name
+}
+
+func (variable *Variable) GetType () (what *Type) {
+        return &variable.what
+}
+
+/* GetDefaultValues returns the values that the variable starts out with.
+ */
+func (variable *Variable) GetDefaultValues () (values []interface {}) {
+        return variable.value
+}
+
+/* GetDefaultSuffix returns the type suffix that the default value at index was
+ * written with, such as u8 or f32. If it had none, an empty string is returned.
+ */
+func (variable *Variable) GetDefaultSuffix (index int) (suffix string) {
+        return getSuffix(variable.suffixes, index)
+}
+
+func (data *Data) GetPosition () (where Position) {
+        return data.where
+}
+
+func (data *Data) GetComments () (comments Comments) {
+        return data.comments
+}
+
+func (data *Data) GetName () (name string) {
+        return data.name
+}
+
+func (data *Data) GetType () (what *Type) {
+        return &data.what
+}
+
+/* GetDefaultValues returns the values that the data starts out with.
+ */
+func (data *Data) GetDefaultValues () (values []interface {}) {
+        return data.value
+}
+
+/* GetDefaultSuffix returns the type suffix that the default value at index was
+ * written with, such as u8 or f32. If it had none, an empty string is returned.
+ */
+func (data *Data) GetDefaultSuffix (index int) (suffix string) {
+        return getSuffix(data.suffixes, index)
+}
+
+func getSuffix (suffixes []string, index int) (suffix string) {
+        if index < 0 || index >= len(suffixes) { return "" }
+        return suffixes[index]
+}
+
+/* GetPermission returns what the module the data is in, and other modules, are
+ * allowed to do with it.
+ */
+func (data *Data) GetPermission () (internal Mode, external Mode) {
+        return data.modeInternal, data.modeExternal
+}
+
+/* IsExternal returns whether the default values of the data were left out,
+ * which happens when the module it is in is skimmed.
+ */
+func (data *Data) IsExternal () (external bool) {
+        return data.external
+}
+
+func (typedef *Typedef) GetPosition () (where Position) {
+        return typedef.where
+}
+
+func (typedef *Typedef) GetComments () (comments Comments) {
+        return typedef.comments
+}
+
+func (typedef *Typedef) GetName () (name string) {
+        return typedef.name
+}
+
+/* GetInherits returns the type that the type definition is based on.
+ */
+func (typedef *Typedef) GetInherits () (inherits *Type) {
+        return &typedef.inherits
+}
+
+/* GetMembers returns the members of the type, in order.
+ */
+func (typedef *Typedef) GetMembers () (members []*Data) {
+        return typedef.members
+}
+
+/* GetPermission returns what the module the type is in, and other modules, are
+ * allowed to do with it.
+ */
+func (typedef *Typedef) GetPermission () (internal Mode, external Mode) {
+        return typedef.modeInternal, typedef.modeExternal
+}
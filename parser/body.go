@@ -1,60 +1,106 @@
 package parser
 
 import "github.com/sashakoshka/arf/lexer"
+import "github.com/sashakoshka/arf/suggest"
+import "github.com/sashakoshka/arf/diagnostic"
+
+/* sectionKinds lists every kind of section that can be in the body of a file.
+ */
+var sectionKinds = []string { "func", "type", "data" }
+
+/* typeQualifiers lists every qualifier that can come after a type.
+ */
+var typeQualifiers = []string { "mut" }
 
 /* parseBody parses the body of an arf file. This contains sections, which have
  * code in them. Returns an error if the file cannot be parsed further.
+ *
+ * Each section parser reports its own mistakes, and always leaves the parser at
+ * the start of the next section, so that one bad section never causes errors
+ * in the ones after it.
  */
 func (parser *Parser) parseBody (skim bool) (err error) {
-        for !parser.endOfFile() {                
-                if parser.line.Indent != 0 {
-                        parser.printError(0, errBadIndent)
-                        return nil
-                }
-                
-                if !parser.expect(lexer.TokenKindName) { continue }
+        for !parser.endOfFile() {
+                parser.beginChunk()
+                err = parser.parseSection(skim)
+                if err != nil { return }
+        }
+        return
+}
 
-                switch parser.token.StringValue {
-                case "data":
-                        parser.nextToken()
-                        section, err := parser.parseBodyData(skim, 0)
-                        if err != nil { return err }
-                        if section != nil {
-                                err = parser.module.addData(section)
-                                if err != nil { parser.printError(5, err) }
-                        }
-                        break
-                case "type":
-                        parser.nextToken()
-                        section, err := parser.parseBodyTypedef(skim)
-                        if err != nil { return err }
-                        if section != nil {
-                                err = parser.module.addTypedef(section)
-                                if err != nil { parser.printError(5, err) }
-                        }
-                        break
-                case "func":
-                        parser.nextToken()
-                        section, err := parser.parseBodyFunction(skim)
-                        if err != nil { return err }
-                        if section != nil {
-                                err = parser.module.addFunction(section)
-                                if err != nil { parser.printError(5, err) }
-                        }
-                        break
-                default:
-                        parser.printError (
-                                0, "unknown section kind \"" +
-                                parser.token.StringValue + "\"")
-                        err = parser.skipBodySection()
-                        if err != nil { return err }
-                        break
-                }
+/* parseSection parses a single top-level section, and leaves the parser at the
+ * line after it.
+ */
+func (parser *Parser) parseSection (skim bool) (err error) {
+        if parser.line.Indent != 0 {
+                parser.printError(diagnostic.CodeBadIndent, 0, errBadIndent)
+                return parser.skipLines(0)
+        }
+        
+        if !parser.expect(lexer.TokenKindName) {
+                return parser.skipBodySection()
+        }
+
+        switch parser.token.StringValue {
+        case "data":
+                parser.nextToken()
+                section, err := parser.parseBodyData(skim, 0)
+                if err == errSkipped { return nil }
+                if err != nil { return err }
+                if section != nil { parser.addSection(section) }
+                break
+        case "type":
+                parser.nextToken()
+                section, err := parser.parseBodyTypedef(skim)
+                if err != nil { return err }
+                if section != nil { parser.addSection(section) }
+                break
+        case "func":
+                parser.nextToken()
+                section, err := parser.parseBodyFunction(skim)
+                if err != nil { return err }
+                if section != nil { parser.addSection(section) }
+                break
+        default:
+                kind := parser.token.StringValue
+                parser.sink.Report (parser.diagnose (
+                        diagnostic.SeverityError,
+                        diagnostic.CodeUnknownSection,
+                        0, "unknown section kind \"" + kind + "\"",
+                ).WithNotes (
+                        suggest.DidYouMean(kind, sectionKinds...)...))
+                return parser.skipBodySection()
         }
         return
 }
 
-/* parseBodyData parses a data section.
+/* printDuplicate reports an error returned from adding a section to the module.
+ * If the error is because the section already exists, the previous definition
+ * is pointed out as well.
+ */
+func (parser *Parser) printDuplicate (where Position, err error) {
+        if err == nil { return }
+
+        mistake := where.diagnose (
+                diagnostic.SeverityError, diagnostic.CodeDuplicateSection,
+                err)
+
+        duplicate, isDuplicate := err.(*errDuplicate)
+        if isDuplicate {
+                previous := duplicate.previous
+                mistake = mistake.WithLabel (
+                        previous.file, previous.row, previous.column,
+                        duplicate.name, "was previously defined here")
+        }
+
+        parser.sink.Report(mistake)
+}
+
+/* parseBodyData parses a data section. It is also used to parse the members of
+ * a type definition, in which case parentIndent is the indentation of the type
+ * definition. Either way, the parser is left at the first line after the data
+ * that is not indented further than parentIndent. If the data has a mistake in
+ * it, errSkipped is returned.
  */
 func (parser *Parser) parseBodyData (
         skim bool,
@@ -64,11 +110,12 @@ func (parser *Parser) parseBodyData (
         err error,
 ) {
         section = &Data {
-                where: parser.embedPosition(),
+                where:    parser.embedPosition(),
+                comments: parser.embedComments(),
         }
 
         if !parser.expect(lexer.TokenKindPermission) {
-                return nil, parser.skipBodySection()
+                return nil, parser.skipMistake(parentIndent)
         }
 
         section.modeInternal,
@@ -78,25 +125,29 @@ func (parser *Parser) parseBodyData (
         // parse it
         if (skim && section.modeExternal == ModeDeny) {
                 section.external = true
-                return nil, parser.skipBodySection()
+                return nil, parser.skipLines(parentIndent)
         }
         
         worked := false
         section.name, section.what, worked, err = parser.parseDeclaration()
         if !worked {
-                return nil, parser.skipBodySection()
+                return nil, parser.skipMistake(parentIndent)
         }
         
         // if we are skimming, don't parse the default values.
         if (skim) {
                 section.external = true
-                return section, parser.skipBodySection()
+                parser.closePosition(&section.where)
+                return section, parser.skipLines(parentIndent)
         }
 
-        section.value, worked, err = parser.parseDefaultValues(parentIndent)
+        section.value,
+        section.suffixes,
+        worked, err = parser.parseDefaultValues(parentIndent)
         if err != nil { return nil, err }
-        if !worked { return nil, parser.skipBodySection() }
+        if !worked { return nil, errSkipped }
 
+        parser.closePosition(&section.where)
         return
 }
 
@@ -109,7 +160,8 @@ func (parser *Parser) parseBodyTypedef (
         err error,
 ) {
         section = &Typedef {
-                where: parser.embedPosition(),
+                where:    parser.embedPosition(),
+                comments: parser.embedComments(),
         }
 
         if !parser.expect(lexer.TokenKindPermission) {
@@ -131,18 +183,25 @@ func (parser *Parser) parseBodyTypedef (
                  return nil, parser.skipBodySection()
         }
 
-        parser.nextToken()
         if !parser.expect() { return nil, parser.skipBodySection() }
 
+        // a member that cannot be parsed is skipped over, and does not affect
+        // the rest of them.
         done := parser.nextLine()
         for {
-                if done || parser.line.Indent == 0 { return }
+                if done || parser.line.Indent == 0 {
+                        parser.closePosition(&section.where)
+                        return
+                }
 
                 member, err := parser.parseBodyData(skim, 1)
+                if err == errSkipped { err = nil }
                 if err != nil { return nil, err }
-                if member == nil { return nil, nil }
-
-                section.members = append(section.members, member)
+                if member != nil {
+                        section.members = append(section.members, member)
+                }
+                
+                done = parser.endOfFile()
         }
 }
 
@@ -150,56 +209,65 @@ func (parser *Parser) parseBodyTypedef (
  * on to the next one.
  */
 func (parser *Parser) skipBodySection () (err error ) {
+        return parser.skipLines(0)
+}
+
+/* skipLines skips the rest of the current line, and all lines after it that are
+ * indented further than indent. This is the main way that the parser recovers
+ * from a mistake: indentation is a reliable sign of where the thing that went
+ * wrong ends.
+ */
+func (parser *Parser) skipLines (indent int) (err error) {
         for {
                 done := parser.nextLine()
-                if done || parser.line.Indent == 0 { return }
+                if done || parser.line.Indent <= indent { return }
         }
 }
 
+/* skipMistake skips lines like skipLines, and returns errSkipped so that the
+ * caller knows that what it was parsing was thrown away.
+ */
+func (parser *Parser) skipMistake (indent int) (err error) {
+        err = parser.skipLines(indent)
+        if err != nil { return }
+        return errSkipped
+}
 
-/* parseDefaultValues parses the default values of a variable.
+/* parseDefaultValues parses the default values of a variable. They start on
+ * the current line, and continue on any lines after it that are indented
+ * further than parentIndent. Whether or not it works, the parser is left at the
+ * first line that is not.
  */
 func (parser *Parser) parseDefaultValues (
         parentIndent int,
 ) (
-        value []interface {},
-        worked bool,
-        err error,
+        value    []interface {},
+        suffixes []string,
+        worked   bool,
+        err      error,
 ) {
         for {
-                if !parser.expect (
-                        lexer.TokenKindNone,
-                        lexer.TokenKindInteger,
-                        lexer.TokenKindSignedInteger,
-                        lexer.TokenKindFloat,
-                        lexer.TokenKindString,
-                        lexer.TokenKindRune,
-                ) { return nil, false, nil }
-                if parser.endOfLine() { break }
-                value = append(value, parser.token.Value)
-                parser.nextToken()
-        }
-        
-        for {
-                done := parser.nextLine()
-                if done || parser.line.Indent <= parentIndent {
-                        worked = true
-                        return
-                }
-
-                for {
+                for !parser.endOfLine() {
                         if !parser.expect (
-                                lexer.TokenKindNone,
                                 lexer.TokenKindInteger,
                                 lexer.TokenKindSignedInteger,
                                 lexer.TokenKindFloat,
                                 lexer.TokenKindString,
                                 lexer.TokenKindRune,
-                        ) { return nil, false, nil }
-                        if parser.endOfLine() { break }
-                        value = append(value, parser.token.Value)
+                        ) {
+                                return nil, nil, false,
+                                        parser.skipLines(parentIndent)
+                        }
+                        
+                        value    = append(value, parser.token.Value)
+                        suffixes = append(suffixes, parser.token.Suffix)
                         parser.nextToken()
                 }
+                
+                done := parser.nextLine()
+                if done || parser.line.Indent <= parentIndent {
+                        return value, suffixes, true, nil
+                }
         }
 }
 
@@ -264,6 +332,8 @@ func (parser *Parser) parseType () (
                 lexer.TokenKindName,
                 lexer.TokenKindLBrace,
         ) { return }
+
+        what.where = parser.embedPosition()
         
         // if the type is braced, we have a pointer
         if parser.token.Kind == lexer.TokenKindLBrace {
@@ -297,10 +367,11 @@ func (parser *Parser) parseType () (
         // if the type is not braced, it is not a pointer
         } else {
                 // get the identifier of this declaration's type
-                what.name = Identifier {}
+                what.name = Identifier { where: parser.embedPosition() }
                 
                 what.name.trail, worked, err = parser.parseIdentifier()
                 if !worked || err != nil { return }
+                parser.closePosition(&what.name.where)
         }
                 
         // get an additional qualifier, if there is one
@@ -316,15 +387,22 @@ func (parser *Parser) parseType () (
                         what.mutable = true
                         break
                 default:
-                        parser.printError (
+                        parser.sink.Report (parser.diagnose (
+                                diagnostic.SeverityError,
+                                diagnostic.CodeUnknownQualifier,
                                 parser.token.Column,
-                                "unknown type qualifier :" + qualifier)
+                                "unknown type qualifier :" + qualifier,
+                        ).WithNotes (
+                                suggest.DidYouMean (
+                                        qualifier,
+                                        typeQualifiers...)...))
                         break
                 }
                 
                 parser.nextToken()
         }
 
+        parser.closePosition(&what.where)
         return what, true, nil
 }
 
@@ -1,6 +1,7 @@
 package parser
 
 import "github.com/sashakoshka/arf/lexer"
+import "github.com/sashakoshka/arf/diagnostic"
 
 /* parseBodyFunction parses a function section.
  */
@@ -11,8 +12,9 @@ func (parser *Parser) parseBodyFunction (
         err error,
 ) {
         section = &Function {
-                where:   parser.embedPosition(),
-                root:   &Block {
+                where:    parser.embedPosition(),
+                comments: parser.embedComments(),
+                root:    &Block {
                         variables: make(map[string] *Variable),
                 },
         }
@@ -42,30 +44,41 @@ func (parser *Parser) parseBodyFunction (
         if !parser.expect() { return nil, parser.skipBodySection() }
                 
         parser.nextLine()
-        if parser.endOfFile() || parser.line.Indent == 0 { return }
 
-        // function arguments
-        inHead := true
-        for inHead {
+        // function arguments. each one of these leaves the parser at the start
+        // of the next line, even if it is not parsed correctly.
+        for {
+                if parser.endOfFile() || parser.line.Indent == 0 {
+                        parser.closePosition(&section.where)
+                        return
+                }
+                
                 if !parser.expect (
                         lexer.TokenKindSeparator,
                         lexer.TokenKindSymbol,
-                ) { return nil, parser.skipBodySection() }
+                ) {
+                        parser.skipLines(1)
+                        continue
+                }
 
-                if parser.token.Kind == lexer.TokenKindSymbol {
-                        err = parser.parseBodyFunctionArgumentFor(section)
-                        if err != nil { return }
+                if parser.token.Kind == lexer.TokenKindSeparator {
+                        parser.nextLine()
+                        break
                 }
                 
-                inHead = parser.token.Kind != lexer.TokenKindSeparator
-
-                parser.nextLine()
-                if parser.endOfFile() || parser.line.Indent == 0 { return }
+                err = parser.parseBodyFunctionArgumentFor(section)
+                if err != nil { return }
+        }
+        
+        if parser.endOfFile() || parser.line.Indent == 0 {
+                parser.closePosition(&section.where)
+                return
         }
 
         // if we are skimming the file, skip over the function content
         if (skim) {
                 section.external = true
+                parser.closePosition(&section.where)
                 return section, parser.skipBodySection()
         }
 
@@ -78,22 +91,25 @@ func (parser *Parser) parseBodyFunction (
                 parser.nextToken()
                 if parser.token.Kind != lexer.TokenKindNone {
                         parser.printError (
+                                diagnostic.CodeAfterExternal,
                                 parser.token.Column,
                                 "nothing should come after external")
-                        return nil, nil
                 }
 
                 section.external = true
+                parser.closePosition(&section.where)
                 return section, parser.skipBodySection()
         }
 
         // function body
         _, err = parser.parseBodyFunctionBlock(0, section.root)
+        parser.closePosition(&section.where)
         return
 }
 
 /* parseBodyFunctionArgumentFor parses a function argument for the specified
- * function.
+ * function. Whether or not it works, the parser is left at the start of the
+ * line after the argument.
  */
 func (parser *Parser) parseBodyFunctionArgumentFor (
         section *Function,
@@ -102,36 +118,57 @@ func (parser *Parser) parseBodyFunctionArgumentFor (
 ) {
         switch parser.token.StringValue {
         case "@":
-                self := &Variable { where: parser.embedPosition() }
+                self := &Variable {
+                        where:    parser.embedPosition(),
+                        comments: parser.embedComments(),
+                }
                 
+                worked := false
                 self.name,
                 self.what,
-                _, err =  parser.parseDeclaration()
+                worked, err =  parser.parseDeclaration()
                 if err != nil { return err }
+                if !worked    { return parser.skipLines(1) }
                 
                 if self.what.points == nil {
                         parser.printError (
+                                diagnostic.CodeBadReceiver,
                                 parser.token.Column,
-                                "method reciever must be a",
+                                "method receiver must be a",
                                 "pointer")
                         break
                 }
                 
+                if self.what.points.points != nil {
+                        parser.printError (
+                                diagnostic.CodeBadReceiver,
+                                parser.token.Column,
+                                "method receiver must point directly to",
+                                "a type")
+                        break
+                }
+                
                 if self.what.mutable {
                         parser.printError (
+                                diagnostic.CodeBadReceiver,
                                 parser.token.Column,
-                                "method reciever cannot be",
+                                "method receiver cannot be",
                                 "mutable")
                         break
                 }
 
-                if len(self.what.name.trail) > 1 {
+                if len(self.what.points.name.trail) > 1 {
                         parser.printError (
+                                diagnostic.CodeBadReceiver,
                                 parser.token.Column,
                                 "cannot use member selection in method",
-                                "reciever type, type name cannot have dots in",
+                                "receiver type, type name cannot have dots in",
                                 "it")
+                        break
                 }
+
+                if !parser.expect() { break }
+                parser.closePosition(&self.where)
                 
                 // add self to function
                 if section.root.addVariable(self) {
@@ -140,82 +177,111 @@ func (parser *Parser) parseBodyFunctionArgumentFor (
                         section.isMember = true
                 } else {
                         parser.printError (
-                                parser.token.Column,
+                                diagnostic.CodeDuplicateVariable,
+                                self.where.column,
                                 "a variable with the name", self.name, "is",
                                 "already defined in this function")
                 }
                 break
 
         case ">":
-                input := &Variable { where: parser.embedPosition() }
+                input := &Variable {
+                        where:    parser.embedPosition(),
+                        comments: parser.embedComments(),
+                }
                 
+                worked := false
                 input.name,
                 input.what,
-                _, err =  parser.parseDeclaration()
+                worked, err =  parser.parseDeclaration()
                 if err != nil { return err }
+                if !worked    { return parser.skipLines(1) }
 
                 if input.what.mutable {
                         parser.printError (
+                                diagnostic.CodeMutableInput,
                                 parser.token.Column,
                                 "function arguments cannot be",
                                 "mutable")
                         break
                 }
 
-                if parser.endOfLine() { break}
-
-                // get default value for input
-                input.value,
-                _, err = parser.parseDefaultValues(1)
-                if err != nil { return err }
+                // get default value for input, if there is one
+                if !parser.endOfLine() {
+                        input.value,
+                        input.suffixes,
+                        worked, err = parser.parseDefaultValues(1)
+                        if err != nil { return err }
+                        if !worked    { return nil }
+                } else {
+                        parser.nextLine()
+                }
+                parser.closePosition(&input.where)
 
                 // add input to function
                 if section.root.addVariable(input) {
                         section.inputs = append(section.inputs, input.name)
                 } else {
-                        parser.printError (
-                                parser.token.Column,
+                        input.where.ReportError (
+                                parser.sink,
+                                diagnostic.CodeDuplicateVariable,
                                 "a variable with the name", input.name, "is",
                                 "already defined in this function")
                 }
-                break
+                return nil
         
         case "<":
-                output := &Variable { where: parser.embedPosition() }
+                output := &Variable {
+                        where:    parser.embedPosition(),
+                        comments: parser.embedComments(),
+                }
                 
+                worked := false
                 output.name,
                 output.what,
-                _, err =  parser.parseDeclaration()
+                worked, err =  parser.parseDeclaration()
                 if err != nil { return err }
-
-                if output.what.mutable {
-                        parser.printWarning (
-                                parser.token.Column,
+                if !worked    { return parser.skipLines(1) }
+
+                if !output.what.mutable {
+                        // the output isn't over yet, so only its declaration
+                        // is pointed out
+                        declaration := output.where
+                        parser.closePosition(&declaration)
+                        declaration.ReportWarning (
+                                parser.sink,
+                                diagnostic.CodeImmutableOutput,
                                 "immutable output, this is useless. consider",
                                 "marking as :mut")
-                        break
                 }
 
-                if parser.endOfLine() { break}
-
-                // get default value for output
-                output.value,
-                _, err = parser.parseDefaultValues(1)
-                if err != nil { return err }
+                // get default value for output, if there is one
+                if !parser.endOfLine() {
+                        output.value,
+                        output.suffixes,
+                        worked, err = parser.parseDefaultValues(1)
+                        if err != nil { return err }
+                        if !worked    { return nil }
+                } else {
+                        parser.nextLine()
+                }
+                parser.closePosition(&output.where)
 
                 // add output to function
                 if section.root.addVariable(output) {
                         section.outputs = append(section.outputs, output.name)
                 } else {
-                        parser.printError (
-                                parser.token.Column,
+                        output.where.ReportError (
+                                parser.sink,
+                                diagnostic.CodeDuplicateVariable,
                                 "a variable with the name", output.name, "is",
                                 "already defined in this function")
                 }
-                break
+                return nil
 
         default:
                 parser.printError (
+                        diagnostic.CodeUnknownArgument,
                         parser.token.Column,
                         "unknown argument type symbol '" +
                         parser.token.StringValue + "',",
@@ -223,12 +289,13 @@ func (parser *Parser) parseBodyFunctionArgumentFor (
                 break
         }
 
-        return nil
+        return parser.skipLines(1)
 }
 
 /* parseBodyFunctionBlock parses a block of function calls. This is done
  * recursively, so it will also parse sub-blocks. If preExisting is non-nil,
- * this function will parse into it.
+ * this function will parse into it. Statements that cannot be parsed are
+ * skipped, and the rest of the block is parsed anyway.
  */
 func (parser *Parser) parseBodyFunctionBlock (
         parentIndent int,
@@ -247,6 +314,7 @@ func (parser *Parser) parseBodyFunctionBlock (
 
         if (parser.line.Indent > 4) {
                 parser.printWarning (
+                        diagnostic.CodeDeepIndent,
                         parser.token.Column,
                         "indentation level of",
                         parser.line.Indent,
@@ -254,7 +322,7 @@ func (parser *Parser) parseBodyFunctionBlock (
                         "consider breaking up this function.")
         }
 
-        for {
+        for !parser.endOfFile() {
                 if parser.line.Indent <= parentIndent {
                         break
                         
@@ -262,11 +330,19 @@ func (parser *Parser) parseBodyFunctionBlock (
                         // we are parsing a statement
                         var statement *Statement
                         var worked bool
+                        start := parser.lineIndex
                         statement,
                         worked, err = parser.parseBodyFunctionStatement (
                                 parentIndent + 1,
                                 true, block)
-                        if err != nil || !worked { return }
+                        if err != nil { return }
+
+                        // the rest of the line should be empty
+                        if !worked || !parser.expect() {
+                                parser.skipBodyFunctionStatement (
+                                        parentIndent + 1, start)
+                                continue
+                        }
 
                         block.items = append (
                                 block.items,
@@ -275,9 +351,7 @@ func (parser *Parser) parseBodyFunctionBlock (
                                 },
                         )
                         
-                        if parser.endOfLine() {
-                                parser.nextLine()
-                        }
+                        parser.nextLine()
                         
                 } else if parser.line.Indent == parentIndent + 2 {
                         // we are parsing a block
@@ -291,18 +365,20 @@ func (parser *Parser) parseBodyFunctionBlock (
                         })
                         
                 } else {
-                        parser.printError(0, errTooMuchIndent)
-                        
+                        parser.printError (
+                                diagnostic.CodeTooMuchIndent, 0,
+                                errTooMuchIndent)
+                        parser.skipLines(parentIndent + 2)
                 }
-
-                if parser.endOfFile() { return }
         }
 
+        parser.closePosition(&block.where)
         return
 }
 
 /* parseBodyFunctionStatement parses a statement in a function body. This is
- * done recursively, and it may eat up more lines than one.
+ * done recursively, and it may eat up more lines than one. If it does not work,
+ * it is up to the caller to skip over the rest of the statement.
  */
 func (parser *Parser) parseBodyFunctionStatement (
         parentIndent      int,
@@ -318,17 +394,17 @@ func (parser *Parser) parseBodyFunctionStatement (
                 where: parser.embedPosition(),
         }
 
+        // only statements that start a line can have comments attached to them
+        if isDirectlyInBlock {
+                statement.comments = parser.embedComments()
+        }
+
         match := parser.expect (
                 lexer.TokenKindLBracket,
                 lexer.TokenKindName,
                 lexer.TokenKindString,
                 lexer.TokenKindSymbol)
-        if !match {
-                // we have no idea what the users intent with that was, so try
-                // to move on to the next statement.
-                parser.skipBodyFunctionStatement(parentIndent, false)
-                return nil, false, nil
-        }
+        if !match { return nil, false, nil }
 
         // if the first token found was a bracket, this statement is wrapped in
         // brackets and we have to do some things differently
@@ -341,11 +417,7 @@ func (parser *Parser) parseBodyFunctionStatement (
                         lexer.TokenKindName,
                         lexer.TokenKindString,
                         lexer.TokenKindSymbol)
-                if !match {
-                        err = parser.skipBodyFunctionStatement (
-                                parentIndent, bracketed)
-                        return nil, false, err
-                }
+                if !match { return nil, false, nil }
         }
 
         if parser.token.Kind == lexer.TokenKindString {
@@ -356,19 +428,19 @@ func (parser *Parser) parseBodyFunctionStatement (
         } else if parser.token.Kind == lexer.TokenKindSymbol {
                 // this statement is an operator
                 statement.command = Identifier {
+                        where: parser.embedPosition(),
                         trail: []string { parser.token.StringValue },
                 }
                 parser.nextToken()
+                parser.closePosition(&statement.command.where)
         } else {
                 // this statement calls a reachable function
+                where := parser.embedPosition()
                 trail, worked, err := parser.parseIdentifier()
-                        if err != nil { return nil, false, err }
-                if !worked {
-                        parser.skipBodyFunctionStatement(parentIndent, bracketed)
-                        return nil, false, nil
-                }
+                if err != nil || !worked { return nil, false, err }
 
-                statement.command = Identifier { trail: trail }
+                statement.command = Identifier { where: where, trail: trail }
+                parser.closePosition(&statement.command.where)
         }
 
         // get statement arguments
@@ -386,23 +458,37 @@ func (parser *Parser) parseBodyFunctionStatement (
                         lexer.TokenKindInteger,
                         lexer.TokenKindSignedInteger,
                         lexer.TokenKindFloat,
-                ) {
-                        err = parser.skipBodyFunctionStatement (
-                                parentIndent, bracketed)
-                        return nil, false, err
-                }
+                ) { return nil, false, nil }
 
                 if (parser.token.Kind == lexer.TokenKindNone) {
                         // if we have brackets, we can continue to parse the
-                        // statement on the next line. if we don't, we are done
-                        // parsing this statement.
-                        if bracketed {
-                                parser.nextLine()
-                        } else {
+                        // statement on the next line, as long as it is
+                        // indented further than the statement. if we don't, we
+                        // are done parsing this statement.
+                        if !bracketed {
                                 complete = true
+                                continue
+                        }
+                        
+                        done := parser.nextLine()
+                        if done || parser.line.Indent <= parentIndent {
+                                statement.where.ReportError (
+                                        parser.sink,
+                                        diagnostic.CodeUnclosed,
+                                        "bracket is never closed")
+                                return nil, false, nil
                         }
                         continue
                 } else if (parser.token.Kind == lexer.TokenKindRBracket) {
+                        if !bracketed {
+                                parser.printError (
+                                        diagnostic.CodeStrayBracket,
+                                        parser.token.Column,
+                                        "unexpected right bracket token,",
+                                        "there is no bracket to close")
+                                return nil, false, nil
+                        }
+                        
                         complete = true
                         parser.nextToken()
                         continue
@@ -414,8 +500,7 @@ func (parser *Parser) parseBodyFunctionStatement (
                 argument,
                 worked, err := parser.parseBodyFunctionStatementArgument (
                         parentIndent, parent)
-                if err != nil { return nil, false, err }
-                if !worked { continue }
+                if err != nil || !worked { return nil, false, err }
 
                 statement.arguments = append (
                         statement.arguments,
@@ -424,27 +509,39 @@ func (parser *Parser) parseBodyFunctionStatement (
 
         // if we don't need to parse a return direction, stop
         if parser.token.Kind != lexer.TokenKindDirection || !isDirectlyInBlock {
+                parser.closePosition(&statement.where)
                 return statement, true, nil
         }
 
         // we need to parse a return direction
+        direction := parser.token
         parser.nextToken()
-        for parser.expect (
-                lexer.TokenKindName,
-                lexer.TokenKindNone,
-                lexer.TokenKindLBracket,
-                lexer.TokenKindString,
-        ) {
-                if parser.token.Kind != lexer.TokenKindName { break }
+        if parser.endOfLine() {
+                mistake := parser.diagnose (
+                        diagnostic.SeverityError,
+                        diagnostic.CodeNoReturnTargets,
+                        direction.Column,
+                        "expected a name or declaration to return to",
+                        "after " + direction.StringValue)
+                mistake.EndColumn = direction.EndColumn
+                parser.sink.Report(mistake)
+                return nil, false, nil
+        }
+        
+        for !parser.endOfLine() {
+                if !parser.expect(lexer.TokenKindName) {
+                        return nil, false, nil
+                }
                 
                 identifier,
                 worked,
                 err := parser.parseBodyFunctionIdentifierOrDeclaration(parent)
-                if err != nil || !worked { return statement, false, err }
+                if err != nil || !worked { return nil, false, err }
 
                 statement.returnsTo = append(statement.returnsTo, identifier)
         }
         
+        parser.closePosition(&statement.where)
         return statement, true, nil
 }
 
@@ -456,6 +553,8 @@ func (parser *Parser) parseBodyFunctionStatementArgument (
         worked bool,
         err error,
 ) {
+        argument.where = parser.embedPosition()
+        
         switch parser.token.Kind {
         case lexer.TokenKindLBracket:
                 childStatement,
@@ -463,11 +562,7 @@ func (parser *Parser) parseBodyFunctionStatementArgument (
                 err := parser.parseBodyFunctionStatement (
                         parentIndent,
                         false, parent)
-                if err != nil { return argument, false, err }
-                if !worked {
-                        parser.nextToken()
-                        return argument, false, nil
-                }
+                if err != nil || !worked { return argument, false, err }
                 
                 argument.kind = ArgumentKindStatement
                 argument.statementValue = childStatement
@@ -507,22 +602,26 @@ func (parser *Parser) parseBodyFunctionStatementArgument (
         case lexer.TokenKindInteger:
                 argument.kind = ArgumentKindInteger
                 argument.integerValue = parser.token.Value.(uint64)
+                argument.suffix = parser.token.Suffix
                 parser.nextToken()
                 break
                 
         case lexer.TokenKindSignedInteger:
                 argument.kind = ArgumentKindSignedInteger
                 argument.signedIntegerValue = parser.token.Value.(int64)
+                argument.suffix = parser.token.Suffix
                 parser.nextToken()
                 break
                 
         case lexer.TokenKindFloat:
                 argument.kind = ArgumentKindFloat
                 argument.floatValue = parser.token.Value.(float64)
+                argument.suffix = parser.token.Suffix
                 parser.nextToken()
                 break
         }
 
+        parser.closePosition(&argument.where)
         return argument, true, nil
 }
 
@@ -538,23 +637,22 @@ func (parser *Parser) parseBodyFunctionIdentifierOrDeclaration (
         worked bool,
         err error,
 ) {
+        where := parser.embedPosition()
         trail, worked, err := parser.parseIdentifier()
-        if err != nil { return nil, false, err }
-        if !worked {
-                parser.nextToken()
-                return nil, false, nil
-        }
+        if err != nil || !worked { return nil, false, err }
 
         identifier = &Identifier {
-                where: parser.embedPosition(),
+                where: where,
                 trail: trail,
         }
+        parser.closePosition(&identifier.where)
         if (parser.token.Kind != lexer.TokenKindColon) {
                 return identifier, true, nil
         }
 
         if len(trail) != 1 {
                 parser.printError (
+                        diagnostic.CodeDottedDeclaration,
                         parser.token.Column,
                         "cannot use member selection in declaration, name ",
                         "cannot have dots in it")
@@ -572,17 +670,19 @@ func (parser *Parser) parseBodyFunctionIdentifierOrDeclaration (
 
         name := trail[0]
         variable := &Variable {
-                where: parser.embedPosition(),
+                where: where,
                 
                 name: name,
                 what: what,
         }
+        parser.closePosition(&variable.where)
 
         // TODO: check all scopes above this
         if !parent.addVariable(variable) {
                 parser.printError (
+                        diagnostic.CodeDuplicateVariable,
                         parser.token.Column,
-                        "a variable with the name", name, "ia already defined",
+                        "a variable with the name", name, "is already defined",
                         "in this block")
                 return nil, false, err
         }
@@ -603,11 +703,20 @@ func (parser *Parser) parseDereference (
         if !parser.expect (lexer.TokenKindLBrace) {
                 return dereference, false, nil
         }
+        dereference.where = parser.embedPosition()
         parser.nextToken()
 
         if (parser.token.Kind == lexer.TokenKindNone) {
                 // if we are at the end of the line, just go on to the next one
-                parser.nextLine()
+                // as long as it is indented further than the statement
+                done := parser.nextLine()
+                if done || parser.line.Indent <= parentIndent {
+                        dereference.where.ReportError (
+                                parser.sink,
+                                diagnostic.CodeUnclosed,
+                                "brace is never closed")
+                        return dereference, false, nil
+                }
         }
         
         if !parser.expect (
@@ -617,16 +726,12 @@ func (parser *Parser) parseDereference (
                 lexer.TokenKindString,
                 lexer.TokenKindInteger,
         ) {
-                parser.skipBodyFunctionDereference()
                 return dereference, false, nil
         }
         
         argument, worked, err := parser.parseBodyFunctionStatementArgument (
                 parentIndent, parent)
-        if err != nil || !worked {
-                parser.skipBodyFunctionDereference()
-                return dereference, false, err
-        }
+        if err != nil || !worked { return dereference, false, err }
 
         dereference.dereferences = &argument
 
@@ -634,7 +739,6 @@ func (parser *Parser) parseDereference (
                 lexer.TokenKindRBrace,
                 lexer.TokenKindInteger,
         ) {
-                parser.skipBodyFunctionDereference()
                 return dereference, false, nil
         }
         
@@ -643,80 +747,40 @@ func (parser *Parser) parseDereference (
                 dereference.offset = parser.token.Value.(uint64)
                 parser.nextToken()
                 if !parser.expect(lexer.TokenKindRBrace) {
-                        parser.skipBodyFunctionDereference()
                         return dereference, false, nil
                 }
         }
         
         parser.nextToken()
 
+        parser.closePosition(&dereference.where)
         return dereference, true, nil
 }
 
-/* skipBodyFunctionBlock skips to the end of the current block. This is done
- * soley based on indentation.
- */
-func (parser *Parser) skipBodyFunctionBlock (parentIndent int) (err error) {
-        for {
-                parser.nextLine()
-                if parser.endOfFile() { break }
-                if parser.line.Indent <= parentIndent { break }
-        }
-
-        return
-}
-
-/* skipBodyFunctionStatement skips to the end of the current statement, or
- * indentation drop.
+/* skipBodyFunctionStatement skips over a statement that could not be parsed,
+ * starting again from the line it begins on. The statement ends at the first
+ * line end where all of its brackets are closed, or right before the first line
+ * that is not indented further than it.
  */
 func (parser *Parser) skipBodyFunctionStatement (
-        parentIndent int,
-        bracketed bool,
+        indent    int,
+        lineIndex int,
 ) (
         err error,
 ) {
-        depth := 1
-        
-        if !bracketed {
-                depth --
-        }
-        
-        for {
-                if parser.endOfFile() { return }
-                if parser.endOfLine() { parser.nextLine() }
-
-                if parser.token.Kind == lexer.TokenKindLBracket { depth ++ }
-                if parser.token.Kind == lexer.TokenKindRBracket { depth -- }
-
-                // if we drop out of the block or exit the statement, we are
-                // done.
-                if parser.line.Indent < parentIndent { break }
-                if depth == 0 { break }
-        
-                parser.nextToken()
-        }
-
+        parser.lineIndex = lineIndex - 1
         parser.nextLine()
-        return
-}
-
-/* skipBodyFunctionDereference skips to the end of the current dereference.
- */
-func (parser *Parser) skipBodyFunctionDereference () (err error) {
-        depth := 1
         
+        depth := 0
         for {
-                if parser.endOfFile() { return }
-                if parser.endOfLine() { parser.nextLine() }
-
-                if parser.token.Kind == lexer.TokenKindLBrace { depth ++ }
-                if parser.token.Kind == lexer.TokenKindRBrace { depth -- }
+                for ; !parser.endOfLine(); parser.nextToken() {
+                        switch parser.token.Kind {
+                        case lexer.TokenKindLBracket: depth ++
+                        case lexer.TokenKindRBracket: depth --
+                        }
+                }
 
-                if depth == 0 { break }
-        
-                parser.nextToken()
+                done := parser.nextLine()
+                if done || depth <= 0 || parser.line.Indent <= indent { return }
         }
-
-        parser.nextToken()
-        return
 }
@@ -0,0 +1,633 @@
+package parser
+
+import "math"
+import "errors"
+import "unicode"
+import "strings"
+import "unicode/utf8"
+import "github.com/sashakoshka/arf/validate"
+
+/* The functions and methods in this file build an AST up from scratch, so that
+ * other programs can generate arf code and write it out with Print. Everything
+ * that is passed in is checked, so that the result can always be printed and
+ * parsed back in again.
+ */
+
+var (
+        errNil            = errors.New("nothing was passed in")
+        errNotPointer     = errors.New("type is not a pointer")
+        errBadReceiver    = errors.New (
+                "method receiver must point directly to a type with no dots " +
+                "in its name, and cannot be mutable")
+        errMutableInput   = errors.New("function arguments cannot be mutable")
+        errEmptyTrail     = errors.New("identifier must have at least one name")
+        errBadDereference = errors.New (
+                "only statements, identifiers, dereferences, strings, and " +
+                "integers can be dereferenced")
+)
+
+/* errInvalid is returned when something passed to a builder function cannot be
+ * written out as arf code.
+ */
+type errInvalid struct {
+        kind  string
+        value string
+}
+
+func (err *errInvalid) Error () (description string) {
+        return "\"" + err.value + "\" is not a valid " + err.kind
+}
+
+/* checkName returns an error if name cannot be used as the name of something.
+ * Names that look like permissions, such as rw, are not allowed either, since
+ * they would be read as permissions.
+ */
+func checkName (name string) (err error) {
+        if !validate.ValidateName(name) || validate.ValidatePermission(name) {
+                return &errInvalid { kind: "name", value: name }
+        }
+        return nil
+}
+
+/* checkTrail returns an error if any of the names in trail are invalid.
+ */
+func checkTrail (trail []string) (err error) {
+        if len(trail) == 0 { return errEmptyTrail }
+        for _, name := range trail {
+                err = checkName(name)
+                if err != nil { return }
+        }
+        return nil
+}
+
+/* checkType returns an error if a type, or the type that it points to, does not
+ * have a valid name.
+ */
+func checkType (what Type) (err error) {
+        if what.points != nil { return checkType(*what.points) }
+        return checkTrail(what.name.trail)
+}
+
+/* checkArgument returns an error if an argument has no value, which happens
+ * when it was not made by one of the functions that create arguments.
+ */
+func checkArgument (argument Argument) (err error) {
+        switch argument.kind {
+        case ArgumentKindNone:
+                return errors.New("argument has no value")
+        case ArgumentKindStatement:
+                if argument.statementValue == nil { return errNil }
+                break
+        case ArgumentKindIdentifier:
+                if argument.identifierValue == nil { return errNil }
+                break
+        case ArgumentKindDereference:
+                if argument.dereferenceValue == nil { return errNil }
+                break
+        }
+        return nil
+}
+
+/* parsePermission checks a permission such as rw, and splits it into the
+ * internal and external modes.
+ */
+func parsePermission (
+        permission string,
+) (
+        internal Mode,
+        external Mode,
+        err      error,
+) {
+        if !validate.ValidatePermission(permission) {
+                err = &errInvalid { kind: "permission", value: permission }
+                return
+        }
+        internal, external = decodePermission(permission)
+        return
+}
+
+/* checkOperator returns an error if symbol would not be read back in as a
+ * single symbol token.
+ */
+func checkOperator (symbol string) (err error) {
+        invalid := &errInvalid { kind: "operator", value: symbol }
+        if symbol == "" || symbol == "---" || symbol == "->" {
+                return invalid
+        }
+        for _, ch := range symbol {
+                if
+                        validate.IsNameStart(ch) || unicode.IsDigit(ch) ||
+                        unicode.IsSpace(ch) || !unicode.IsGraphic(ch) ||
+                        strings.ContainsRune("\"'`:.[]{}#", ch) {
+
+                        return invalid
+                }
+        }
+        return nil
+}
+
+/* normalizeValue checks that value is something that arf has a literal for, and
+ * converts it to the type that the parser would have produced for that
+ * literal. Ints are turned into uint64 if they are positive, and int64 if they
+ * are negative.
+ */
+func normalizeValue (value interface {}) (normalized interface {}, err error) {
+        switch value := value.(type) {
+        case int:
+                if value < 0 { return int64(value), nil }
+                return uint64(value), nil
+        case uint64, int64, rune:
+                return value, nil
+        case string:
+                if !utf8.ValidString(value) {
+                        return nil, errors.New("string is not valid UTF-8")
+                }
+                return value, nil
+        case float64:
+                if math.IsNaN(value) || math.IsInf(value, 0) {
+                        return nil, errors.New (
+                                "NaN and infinity cannot be written in arf")
+                }
+                return value, nil
+        }
+        return nil, errors.New("value has no literal in arf")
+}
+
+/* normalizeValues normalizes each value in a list of default values.
+ */
+func normalizeValues (
+        values []interface {},
+) (
+        normalized []interface {},
+        err        error,
+) {
+        for _, value := range values {
+                value, err = normalizeValue(value)
+                if err != nil { return nil, err }
+                normalized = append(normalized, value)
+        }
+        return
+}
+
+/* NewModule creates an empty module with the specified name.
+ */
+func NewModule (name string) (module *Module, err error) {
+        err = checkName(name)
+        if err != nil { return }
+
+        module = &Module {
+                name:      name,
+                functions: make(map[string] *Function),
+                typedefs:  make(map[string] *Typedef),
+                datas:     make(map[string] *Data),
+        }
+        return
+}
+
+/* SetAuthor sets the author field of the module's metadata.
+ */
+func (module *Module) SetAuthor (author string) {
+        module.author = author
+}
+
+/* SetLicense sets the license field of the module's metadata.
+ */
+func (module *Module) SetLicense (license string) {
+        module.license = license
+}
+
+/* AddImport adds a module that this module requires.
+ */
+func (module *Module) AddImport (path string) {
+        module.imports = append(module.imports, path)
+}
+
+/* AddFunction adds a function section to the module. If there is already a
+ * function with the same name, an error is returned.
+ */
+func (module *Module) AddFunction (function *Function) (err error) {
+        if function == nil { return errNil }
+        return module.addFunction(function)
+}
+
+/* AddTypedef adds a type section to the module. If there is already a type with
+ * the same name, an error is returned.
+ */
+func (module *Module) AddTypedef (typedef *Typedef) (err error) {
+        if typedef == nil { return errNil }
+        return module.addTypedef(typedef)
+}
+
+/* AddData adds a data section to the module. If there is already a data
+ * section with the same name, an error is returned.
+ */
+func (module *Module) AddData (data *Data) (err error) {
+        if data == nil { return errNil }
+        return module.addData(data)
+}
+
+/* NewType creates a type that refers to another type by name. The trail is
+ * each of the dot separated names in it.
+ */
+func NewType (trail ...string) (what Type, err error) {
+        err = checkTrail(trail)
+        if err != nil { return }
+        what.name.trail = trail
+        return
+}
+
+/* NewPointerType creates a type that points to items of another type. If items
+ * is zero, the pointer has no count.
+ */
+func NewPointerType (points Type, items uint64) (what Type) {
+        what.points = &points
+        what.items  = items
+        return
+}
+
+/* SetMutable sets whether the type has the :mut qualifier.
+ */
+func (what *Type) SetMutable (mutable bool) {
+        what.mutable = mutable
+}
+
+/* NewData creates a data section, which can also be used as a member of a type
+ * definition. Values are the default values of the data, and can be integers,
+ * floats, strings, or runes.
+ */
+func NewData (
+        permission string,
+        name       string,
+        what       Type,
+        values     ...interface {},
+) (
+        data *Data,
+        err  error,
+) {
+        data = &Data { name: name, what: what }
+
+        data.modeInternal, data.modeExternal, err = parsePermission(permission)
+        if err != nil { return nil, err }
+        err = checkName(name)
+        if err != nil { return nil, err }
+        err = checkType(what)
+        if err != nil { return nil, err }
+        data.value, err = normalizeValues(values)
+        if err != nil { return nil, err }
+        return
+}
+
+/* NewTypedef creates a type section that inherits from another type.
+ */
+func NewTypedef (
+        permission string,
+        name       string,
+        inherits   Type,
+) (
+        typedef *Typedef,
+        err     error,
+) {
+        typedef = &Typedef { name: name, inherits: inherits }
+
+        typedef.modeInternal,
+        typedef.modeExternal, err = parsePermission(permission)
+        if err != nil { return nil, err }
+        err = checkName(name)
+        if err != nil { return nil, err }
+        err = checkType(inherits)
+        if err != nil { return nil, err }
+        return
+}
+
+/* AddMember adds a member to the type definition.
+ */
+func (typedef *Typedef) AddMember (member *Data) (err error) {
+        if member == nil { return errNil }
+        typedef.members = append(typedef.members, member)
+        return
+}
+
+/* NewFunction creates a function section with an empty root block.
+ */
+func NewFunction (
+        permission string,
+        name       string,
+) (
+        function *Function,
+        err      error,
+) {
+        function = &Function {
+                name: name,
+                root: NewBlock(),
+        }
+
+        function.modeInternal,
+        function.modeExternal, err = parsePermission(permission)
+        if err != nil { return nil, err }
+        err = checkName(name)
+        if err != nil { return nil, err }
+        return
+}
+
+/* SetSelf makes the function a method, with a receiver called name. The type
+ * of the receiver must be an immutable pointer to a type with no dots in its
+ * name.
+ */
+func (function *Function) SetSelf (name string, what Type) (err error) {
+        if function.isMember {
+                return errors.New("function already has a receiver")
+        }
+        if what.points == nil { return errNotPointer }
+        if what.points.points != nil || what.mutable ||
+                len(what.points.name.trail) != 1 { return errBadReceiver }
+        err = checkType(what)
+        if err != nil { return }
+
+        err = function.addArgument(name, what, nil)
+        if err != nil { return }
+
+        function.isMember = true
+        function.self     = name
+        function.selfType = what.points.name.trail[0]
+        return
+}
+
+/* AddInput adds an input argument to the function. Inputs cannot be mutable.
+ * Values are the default values of the argument.
+ */
+func (function *Function) AddInput (
+        name   string,
+        what   Type,
+        values ...interface {},
+) (
+        err error,
+) {
+        if what.mutable { return errMutableInput }
+        err = function.addArgument(name, what, values)
+        if err != nil { return }
+        function.inputs = append(function.inputs, name)
+        return
+}
+
+/* AddOutput adds an output argument to the function. Values are the default
+ * values of the argument.
+ */
+func (function *Function) AddOutput (
+        name   string,
+        what   Type,
+        values ...interface {},
+) (
+        err error,
+) {
+        err = function.addArgument(name, what, values)
+        if err != nil { return }
+        function.outputs = append(function.outputs, name)
+        return
+}
+
+/* addArgument adds a variable for an argument to the root block of the
+ * function.
+ */
+func (function *Function) addArgument (
+        name   string,
+        what   Type,
+        values []interface {},
+) (
+        err error,
+) {
+        err = checkName(name)
+        if err != nil { return }
+        err = checkType(what)
+        if err != nil { return }
+
+        variable := &Variable { name: name, what: what }
+        variable.value, err = normalizeValues(values)
+        if err != nil { return }
+
+        if !function.root.addVariable(variable) {
+                return errors.New (
+                        "a variable with the name " + name +
+                        " is already defined in this function")
+        }
+        return
+}
+
+/* SetExternal sets whether the function is defined outside of arf. The root
+ * block of an external function is not printed.
+ */
+func (function *Function) SetExternal (external bool) {
+        function.external = external
+}
+
+/* NewBlock creates an empty block.
+ */
+func NewBlock () (block *Block) {
+        return &Block { variables: make(map[string] *Variable) }
+}
+
+/* AddStatement adds a statement to the end of the block.
+ */
+func (block *Block) AddStatement (statement *Statement) (err error) {
+        if statement == nil { return errNil }
+        block.items = append(block.items, BlockOrStatement {
+                statement: statement,
+        })
+        return
+}
+
+/* AddBlock adds a child block to the end of the block. Arf only has
+ * indentation to tell blocks apart, so a block cannot be added directly after
+ * another one.
+ */
+func (block *Block) AddBlock (child *Block) (err error) {
+        if child == nil { return errNil }
+        count := len(block.items)
+        if count > 0 && block.items[count - 1].block != nil {
+                return errors.New("a block cannot come directly after another")
+        }
+        block.items = append(block.items, BlockOrStatement { block: child })
+        return
+}
+
+/* Declare adds a variable to the block, and returns an identifier that refers
+ * to it. Variables are declared where they are first used, so the identifier
+ * must be used in a statement directly in this block for the block to be
+ * printed.
+ */
+func (block *Block) Declare (
+        name string,
+        what Type,
+) (
+        identifier *Identifier,
+        err        error,
+) {
+        err = checkName(name)
+        if err != nil { return }
+        err = checkType(what)
+        if err != nil { return }
+
+        if !block.addVariable(&Variable { name: name, what: what }) {
+                return nil, errors.New (
+                        "a variable with the name " + name +
+                        " is already defined in this block")
+        }
+        return &Identifier { trail: []string { name } }, nil
+}
+
+/* NewIdentifier creates an identifier from each of the dot separated names in
+ * it.
+ */
+func NewIdentifier (trail ...string) (identifier *Identifier, err error) {
+        err = checkTrail(trail)
+        if err != nil { return }
+        return &Identifier { trail: trail }, nil
+}
+
+/* NewStatement creates a statement that calls the function named by each of
+ * the dot separated names in command.
+ */
+func NewStatement (command ...string) (statement *Statement, err error) {
+        err = checkTrail(command)
+        if err != nil { return }
+        return &Statement { command: Identifier { trail: command } }, nil
+}
+
+/* NewOperator creates a statement that calls an operator, such as + or =.
+ */
+func NewOperator (symbol string) (statement *Statement, err error) {
+        err = checkOperator(symbol)
+        if err != nil { return }
+        statement = &Statement {
+                command: Identifier { trail: []string { symbol } },
+        }
+        return
+}
+
+/* NewExternalStatement creates a statement that calls a function outside of
+ * arf, which can have any name that is not empty.
+ */
+func NewExternalStatement (command string) (statement *Statement, err error) {
+        if command == "" || !utf8.ValidString(command) {
+                return nil, &errInvalid {
+                        kind:  "external command",
+                        value: command,
+                }
+        }
+        statement = &Statement {
+                external:        true,
+                externalCommand: command,
+        }
+        return
+}
+
+/* AddArgument adds an argument to the end of the statement.
+ */
+func (statement *Statement) AddArgument (argument Argument) (err error) {
+        err = checkArgument(argument)
+        if err != nil { return }
+        statement.arguments = append(statement.arguments, argument)
+        return
+}
+
+/* AddReturn adds an identifier that the results of the statement are put into.
+ * Only statements directly in a block can return to something.
+ */
+func (statement *Statement) AddReturn (identifier *Identifier) (err error) {
+        if identifier == nil { return errNil }
+        statement.returnsTo = append(statement.returnsTo, identifier)
+        return
+}
+
+/* NewStatementArgument creates an argument that is the result of another
+ * statement.
+ */
+func NewStatementArgument (
+        statement *Statement,
+) (
+        argument Argument,
+        err      error,
+) {
+        if statement == nil { return argument, errNil }
+        argument.kind = ArgumentKindStatement
+        argument.statementValue = statement
+        return
+}
+
+/* NewIdentifierArgument creates an argument that refers to something by name.
+ */
+func NewIdentifierArgument (
+        identifier *Identifier,
+) (
+        argument Argument,
+        err      error,
+) {
+        if identifier == nil { return argument, errNil }
+        argument.kind = ArgumentKindIdentifier
+        argument.identifierValue = identifier
+        return
+}
+
+/* NewDereferenceArgument creates an argument that dereferences another one,
+ * offset by a number of items.
+ */
+func NewDereferenceArgument (
+        dereferences Argument,
+        offset       uint64,
+) (
+        argument Argument,
+        err      error,
+) {
+        switch dereferences.kind {
+        case
+                ArgumentKindStatement,
+                ArgumentKindIdentifier,
+                ArgumentKindDereference,
+                ArgumentKindString,
+                ArgumentKindInteger:
+
+                break
+        default:
+                return argument, errBadDereference
+        }
+        err = checkArgument(dereferences)
+        if err != nil { return }
+
+        argument.kind = ArgumentKindDereference
+        argument.dereferenceValue = &Dereference {
+                dereferences: &dereferences,
+                offset:       offset,
+        }
+        return
+}
+
+/* NewValueArgument creates an argument from a literal value, which can be an
+ * integer, float, string, or rune.
+ */
+func NewValueArgument (value interface {}) (argument Argument, err error) {
+        value, err = normalizeValue(value)
+        if err != nil { return }
+
+        switch value := value.(type) {
+        case uint64:
+                argument.kind = ArgumentKindInteger
+                argument.integerValue = value
+                break
+        case int64:
+                argument.kind = ArgumentKindSignedInteger
+                argument.signedIntegerValue = value
+                break
+        case float64:
+                argument.kind = ArgumentKindFloat
+                argument.floatValue = value
+                break
+        case string:
+                argument.kind = ArgumentKindString
+                argument.stringValue = value
+                break
+        case rune:
+                argument.kind = ArgumentKindRune
+                argument.runeValue = value
+                break
+        }
+        return
+}
@@ -0,0 +1,220 @@
+package parser_test
+
+import "bytes"
+import "testing"
+import "github.com/sashakoshka/arf/parser"
+
+/* TestBuilderRejects checks that the builder returns an error when it is given
+ * something that could not be printed, instead of failing later on.
+ */
+func TestBuilderRejects (test *testing.T) {
+        integer, err := parser.NewType("Int")
+        if err != nil { test.Fatal(err) }
+        typedef, err := parser.NewTypedef("rr", "Thing", integer)
+        if err != nil { test.Fatal(err) }
+        function, err := parser.NewFunction("rr", "thing")
+        if err != nil { test.Fatal(err) }
+        statement, err := parser.NewStatement("io", "println")
+        if err != nil { test.Fatal(err) }
+        module, err := parser.NewModule("builder")
+        if err != nil { test.Fatal(err) }
+
+        cases := []struct {
+                name string
+                try  func () error
+        } {
+                { "nil identifier argument", func () error {
+                        _, err := parser.NewIdentifierArgument(nil)
+                        return err
+                } },
+                { "nil statement argument", func () error {
+                        _, err := parser.NewStatementArgument(nil)
+                        return err
+                } },
+                { "empty argument", func () error {
+                        return statement.AddArgument(parser.Argument { })
+                } },
+                { "dereference of empty argument", func () error {
+                        _, err := parser.NewDereferenceArgument (
+                                parser.Argument { }, 0)
+                        return err
+                } },
+                { "nil member", func () error {
+                        return typedef.AddMember(nil)
+                } },
+                { "nil statement", func () error {
+                        return function.GetRoot().AddStatement(nil)
+                } },
+                { "nil block", func () error {
+                        return function.GetRoot().AddBlock(nil)
+                } },
+                { "nil return", func () error {
+                        return statement.AddReturn(nil)
+                } },
+                { "nil data", func () error {
+                        return module.AddData(nil)
+                } },
+                { "nil typedef", func () error {
+                        return module.AddTypedef(nil)
+                } },
+                { "nil function", func () error {
+                        return module.AddFunction(nil)
+                } },
+                { "data with empty type", func () error {
+                        _, err := parser.NewData("rr", "thing", parser.Type { })
+                        return err
+                } },
+                { "data pointing to empty type", func () error {
+                        _, err := parser.NewData (
+                                "rr", "thing",
+                                parser.NewPointerType(parser.Type { }, 0))
+                        return err
+                } },
+                { "typedef with empty type", func () error {
+                        _, err := parser.NewTypedef (
+                                "rr", "Other", parser.Type { })
+                        return err
+                } },
+                { "input with empty type", func () error {
+                        return function.AddInput("argc", parser.Type { })
+                } },
+                { "variable with empty type", func () error {
+                        _, err := function.GetRoot().Declare (
+                                "thing", parser.Type { })
+                        return err
+                } },
+                { "empty external command", func () error {
+                        _, err := parser.NewExternalStatement("")
+                        return err
+                } },
+                { "invalid external command", func () error {
+                        _, err := parser.NewExternalStatement("\xff")
+                        return err
+                } },
+                { "invalid string", func () error {
+                        _, err := parser.NewValueArgument("\xff")
+                        return err
+                } },
+        }
+
+        for _, testCase := range cases {
+                if testCase.try() == nil {
+                        test.Errorf("%s was accepted", testCase.name)
+                }
+        }
+}
+
+/* TestBuilderRoundTrip builds a module from scratch, prints it, and checks that
+ * parsing what was printed gives back a module that prints out the same way.
+ */
+func TestBuilderRoundTrip (test *testing.T) {
+        check := func (err error) {
+                test.Helper()
+                if err != nil { test.Fatal(err) }
+        }
+
+        module, err := parser.NewModule("print")
+        check(err)
+        module.SetAuthor("someone")
+        module.SetLicense("MIT")
+        module.AddImport("io")
+
+        integer, err := parser.NewType("Int")
+        check(err)
+        text, err := parser.NewType("String")
+        check(err)
+        object, err := parser.NewType("Obj")
+        check(err)
+
+        data, err := parser.NewData("rr", "count", integer, 5, -3, 2.5)
+        check(err)
+        check(module.AddData(data))
+
+        greeter, err := parser.NewTypedef("rr", "Greeter", object)
+        check(err)
+        member, err := parser.NewData("rw", "text", text, "hi\n")
+        check(err)
+        check(greeter.AddMember(member))
+        check(module.AddTypedef(greeter))
+
+        function, err := parser.NewFunction("rr", "main")
+        check(err)
+        check(function.AddInput("argc", integer, 1))
+        status := integer
+        status.SetMutable(true)
+        check(function.AddOutput("status", status, 0))
+        root := function.GetRoot()
+
+        // let value:Int:mut
+        // set value [+ argc {value} 'a']
+        declared, err := root.Declare("value", status)
+        check(err)
+        let, err := parser.NewStatement("let")
+        check(err)
+        argument, err := parser.NewIdentifierArgument(declared)
+        check(err)
+        check(let.AddArgument(argument))
+        check(root.AddStatement(let))
+
+        set, err := parser.NewStatement("set")
+        check(err)
+        value, err := parser.NewIdentifier("value")
+        check(err)
+        argument, err = parser.NewIdentifierArgument(value)
+        check(err)
+        check(set.AddArgument(argument))
+
+        add, err := parser.NewOperator("+")
+        check(err)
+        argc, err := parser.NewIdentifier("argc")
+        check(err)
+        argument, err = parser.NewIdentifierArgument(argc)
+        check(err)
+        check(add.AddArgument(argument))
+        argument, err = parser.NewIdentifierArgument(value)
+        check(err)
+        argument, err = parser.NewDereferenceArgument(argument, 0)
+        check(err)
+        check(add.AddArgument(argument))
+        argument, err = parser.NewValueArgument('a')
+        check(err)
+        check(add.AddArgument(argument))
+        argument, err = parser.NewStatementArgument(add)
+        check(err)
+        check(set.AddArgument(argument))
+        check(root.AddStatement(set))
+
+        // a child block, calling a function outside of arf
+        block := parser.NewBlock()
+        external, err := parser.NewExternalStatement("puts")
+        check(err)
+        argument, err = parser.NewValueArgument("done")
+        check(err)
+        check(external.AddArgument(argument))
+        check(block.AddStatement(external))
+        check(root.AddBlock(block))
+
+        // io.println "hello" -> status
+        println, err := parser.NewStatement("io", "println")
+        check(err)
+        argument, err = parser.NewValueArgument("hello")
+        check(err)
+        check(println.AddArgument(argument))
+        returnTo, err := parser.NewIdentifier("status")
+        check(err)
+        check(println.AddReturn(returnTo))
+        check(root.AddStatement(println))
+        check(module.AddFunction(function))
+
+        first := &bytes.Buffer { }
+        check(module.Print(first))
+
+        second := printModule (
+                test, parseDirectory(test, first.String(), true), "parsed")
+        if first.String() != second {
+                test.Errorf (
+                        "printing the built module gives:\n%s\n" +
+                        "but printing it after parsing that gives:\n%s",
+                        first.String(), second)
+        }
+}
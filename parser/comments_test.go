@@ -0,0 +1,94 @@
+package parser_test
+
+import "testing"
+import "github.com/sashakoshka/arf/parser"
+import "github.com/sashakoshka/arf/diagnostic"
+
+func TestComments (test *testing.T) {
+        sources := map[string] []byte {
+                "comments.arf": []byte (
+`:arf
+module comments
+---
+
+# the first line
+# the second line
+data rr text:String "hello" # trailing
+
+# a type
+type rr Greeter:Obj
+        # a member
+        rw text:String
+
+func rr main
+        ---
+        # a statement
+        io.println text # after a statement
+`),
+        }
+
+        collector := &diagnostic.Collector { }
+        module, err := parser.ParseSources (
+                "comments", sources, false, collector)
+        if err != nil { test.Fatal(err) }
+        for _, mistake := range collector.GetDiagnostics() {
+                test.Error("unexpected diagnostic:", mistake.Message)
+        }
+
+        data, _ := module.GetData("text")
+        dataComments := data.GetComments()
+        expectComments (
+                test, "data", dataComments,
+                []string { "# the first line", "# the second line" },
+                "# trailing")
+
+        typedef, _ := module.GetTypedef("Greeter")
+        typedefComments := typedef.GetComments()
+        expectComments (
+                test, "type", typedefComments,
+                []string { "# a type" }, "")
+
+        members := typedef.GetMembers()
+        if len(members) != 1 { test.Fatal("wrong number of members") }
+        memberComments := members[0].GetComments()
+        expectComments (
+                test, "member", memberComments,
+                []string { "# a member" }, "")
+
+        function, _ := module.GetFunction("main")
+        items := function.GetRoot().GetItems()
+        if len(items) != 1 { test.Fatal("wrong number of statements") }
+        statementComments := items[0].GetStatement().GetComments()
+        expectComments (
+                test, "statement", statementComments,
+                []string { "# a statement" }, "# after a statement")
+}
+
+func expectComments (
+        test     *testing.T,
+        name     string,
+        comments parser.Comments,
+        leading  []string,
+        trailing string,
+) {
+        got := comments.GetLeading()
+        if len(got) != len(leading) {
+                test.Errorf (
+                        "%s has leading comments %q, expected %q",
+                        name, got, leading)
+        } else {
+                for index := range got {
+                        if got[index] == leading[index] { continue }
+                        test.Errorf (
+                                "%s has leading comments %q, expected %q",
+                                name, got, leading)
+                        break
+                }
+        }
+
+        if comments.GetTrailing() != trailing {
+                test.Errorf (
+                        "%s has trailing comment %q, expected %q",
+                        name, comments.GetTrailing(), trailing)
+        }
+}
@@ -19,6 +19,7 @@ func (module *Module) Dump () {
         }
 
         for _, section := range module.typedefs {
+                section.comments.dumpLeading(0)
                 fmt.Print("type ")
 
                 switch section.modeInternal {
@@ -33,9 +34,10 @@ func (module *Module) Dump () {
                         case ModeWrite: fmt.Print("w")
                 }
 
-                fmt.Println (
-                        "", section.name +
+                fmt.Print (
+                        " " + section.name +
                         ":" + section.inherits.ToString())
+                section.comments.dumpTrailing()
 
                 for _, member := range section.members {
                         member.Dump(1)
@@ -48,6 +50,7 @@ func (module *Module) Dump () {
 }
 
 func (data *Data) Dump (indent int) {
+        data.comments.dumpLeading(indent)
         printIndent(indent)
         if indent == 0 { fmt.Print("data ") }
 
@@ -63,7 +66,8 @@ func (data *Data) Dump (indent int) {
                 case ModeWrite: fmt.Print("w")
         }
 
-        fmt.Println("", data.name + ":" + data.what.ToString())
+        fmt.Print(" " + data.name + ":" + data.what.ToString())
+        data.comments.dumpTrailing()
 
         if data.external {
                 fmt.Println("        external")
@@ -76,6 +80,7 @@ func (data *Data) Dump (indent int) {
 }
 
 func (function *Function) Dump () {
+        function.comments.dumpLeading(0)
         fmt.Print("func ")
 
         switch function.modeInternal {
@@ -90,22 +95,29 @@ func (function *Function) Dump () {
                 case ModeWrite: fmt.Print("w")
         }
 
-        fmt.Println("", function.name)
+        fmt.Print(" " + function.name)
+        function.comments.dumpTrailing()
 
         if function.isMember {
-                fmt.Println (
-                        "        @",
+                selfData := function.root.variables[function.self]
+                
+                selfData.comments.dumpLeading(1)
+                fmt.Print (
+                        "        @ ",
                         function.self + ":" +
-                        function.root.variables[function.self].what.ToString())
+                        selfData.what.ToString())
+                selfData.comments.dumpTrailing()
         }
 
         for _, input := range function.inputs {
                 inputData := function.root.variables[input]
                 
-                fmt.Println (
-                        "        >",
+                inputData.comments.dumpLeading(1)
+                fmt.Print (
+                        "        > ",
                         inputData.name + ":" +
                         inputData.what.ToString())
+                inputData.comments.dumpTrailing()
                         
                 for _, value := range inputData.value {
                         printIndent(2)
@@ -116,10 +128,12 @@ func (function *Function) Dump () {
         for _, output := range function.outputs {
                 ouputData := function.root.variables[output]
                 
-                fmt.Println (
-                        "        <",
+                ouputData.comments.dumpLeading(1)
+                fmt.Print (
+                        "        < ",
                         ouputData.name + ":" +
                         ouputData.what.ToString())
+                ouputData.comments.dumpTrailing()
                         
                 for _, value := range ouputData.value {
                         printIndent(2)
@@ -158,6 +172,7 @@ func (block *Block) Dump (indent int) {
 }
 
 func (statement *Statement) Dump (indent int) {
+        statement.comments.dumpLeading(indent)
         printIndent(indent)
         fmt.Print("[")
         if (statement.external) {
@@ -177,6 +192,10 @@ func (statement *Statement) Dump (indent int) {
                         fmt.Print(" ", identifier.ToString())
                 }
         }
+
+        if statement.comments.trailing != "" {
+                fmt.Print(" ", statement.comments.trailing)
+        }
 }
 
 func (dereference *Dereference) Dump (indent int) {
@@ -253,6 +272,24 @@ func (identifier *Identifier) ToString () (description string) {
         return
 }
 
+/* dumpLeading prints leading comments, each on its own line.
+ */
+func (comments Comments) dumpLeading (indent int) {
+        for _, comment := range comments.leading {
+                printIndent(indent)
+                fmt.Println(comment)
+        }
+}
+
+/* dumpTrailing prints the trailing comment, if there is one, and ends the line.
+ */
+func (comments Comments) dumpTrailing () {
+        if comments.trailing != "" {
+                fmt.Print(" ", comments.trailing)
+        }
+        fmt.Println()
+}
+
 func printIndent (level int) {
         for level > 0 {
                 level --
@@ -0,0 +1,238 @@
+package parser
+
+import "sync"
+import "runtime"
+import "github.com/sashakoshka/arf/lexer"
+import "github.com/sashakoshka/arf/lineFile"
+import "github.com/sashakoshka/arf/diagnostic"
+
+/* fileSource loads a file that is part of a module. If the file cannot be
+ * loaded, it should still be returned along with the error, so that the error
+ * can be reported in it.
+ */
+type fileSource func () (file *lineFile.LineFile, err error)
+
+/* sourceFile holds everything that was found in a single file of a module. The
+ * module is built by merging these together, which means that when a file
+ * changes, only its sourceFile needs to be redone.
+ */
+type sourceFile struct {
+        file *lineFile.LineFile
+
+        // metadata holds the fields from the header of the file.
+        metadata *Module
+
+        // suppressions are the warnings allowed within the file, and
+        // scanned holds the diagnostics from looking for them.
+        suppressions []diagnostic.Suppression
+        scanned      []diagnostic.Diagnostic
+
+        // header is everything before the first section, and each chunk
+        // after it is one section, along with the comments above it.
+        header chunk
+        chunks []*chunk
+
+        // broken is true if the file could not be parsed all the way to the
+        // end. A file like this can only be reparsed as a whole.
+        broken bool
+}
+
+/* chunk is a range of rows in a file that is parsed on its own. Apart from the
+ * header, each chunk is a single top-level section: it starts at the comments
+ * directly above the section, and ends where the next chunk starts.
+ */
+type chunk struct {
+        startRow int
+        endRow   int
+
+        // first is the first line of code in the chunk. It is kept so that
+        // the chunk before this one can be reparsed without lexing this one.
+        first *lexer.Line
+
+        // lexed holds the diagnostics that the lexer reported within the
+        // chunk, and parsed holds the ones that the parser reported.
+        lexed  []diagnostic.Diagnostic
+        parsed []diagnostic.Diagnostic
+
+        sections []parsedSection
+}
+
+/* parsedSection is a section that has been parsed from a file, but has not yet
+ * been added to the module. Reported is how many diagnostics had been reported
+ * in its chunk by the time it was parsed.
+ */
+type parsedSection struct {
+        section  interface {}
+        reported int
+}
+
+/* parseFiles parses several files into the module at the same time. Each file
+ * gets a parser of its own, which collects the sections and diagnostics that
+ * come out of it instead of adding and reporting them straight away. Once
+ * every file is done, the results are merged into the module in the same order
+ * that the sources were given in. This way, duplicate sections and diagnostics
+ * come out exactly the same as if the files had been parsed one at a time.
+ */
+func (parser *Parser) parseFiles (sources []fileSource, skim bool) {
+        results := make([]*sourceFile, len(sources))
+        limit   := make(chan struct { }, runtime.GOMAXPROCS(0))
+
+        var group sync.WaitGroup
+        for index, source := range sources {
+                group.Add(1)
+                go func (index int, source fileSource) {
+                        defer group.Done()
+                        limit <- struct { } { }
+                        results[index] = parser.parseFileAlone(source, skim)
+                        <- limit
+                } (index, source)
+        }
+        group.Wait()
+
+        parser.module.sources = results
+        parser.module.skim    = skim
+        for _, result := range results {
+                parser.merge(result)
+        }
+}
+
+/* parseFileAlone loads and parses a single file using a new parser that does
+ * not share anything with the others.
+ */
+func (parser *Parser) parseFileAlone (
+        source fileSource,
+        skim   bool,
+) (
+        result *sourceFile,
+) {
+        fileParser := &Parser {
+                directory: parser.directory,
+                module:    &Module {
+                        name: parser.module.name,
+                        path: parser.module.path,
+                },
+        }
+        fileParser.sink = diagnostic.SinkFunc(fileParser.record)
+
+        file, err := source()
+        fileParser.file   = file
+        fileParser.source = &sourceFile {
+                file:     file,
+                metadata: fileParser.module,
+                broken:   true,
+        }
+        fileParser.chunk = &fileParser.source.header
+
+        if err != nil {
+                fileParser.printFatal(err)
+                return fileParser.source
+        }
+
+        fileParser.parseFile(file, skim)
+        return fileParser.source
+}
+
+/* record holds on to a diagnostic that was reported while parsing a file, so
+ * that it can be reported once the file is merged into the module.
+ */
+func (parser *Parser) record (mistake diagnostic.Diagnostic) {
+        parser.chunk.parsed = append(parser.chunk.parsed, mistake)
+}
+
+/* beginChunk starts a new chunk at the current line.
+ */
+func (parser *Parser) beginChunk () {
+        startRow := parser.line.Row
+        if len(parser.line.Comments) > 0 {
+                startRow = parser.line.Comments[0].Row
+        }
+
+        parser.chunk.endRow = startRow
+        parser.chunk = &chunk {
+                startRow: startRow,
+                endRow:   parser.file.GetLength(),
+                first:    parser.line,
+        }
+        parser.source.chunks = append(parser.source.chunks, parser.chunk)
+}
+
+/* addSection holds on to a section that has just been parsed, so that it can be
+ * added to the module once the file is merged into it.
+ */
+func (parser *Parser) addSection (section interface {}) {
+        parser.chunk.sections = append (parser.chunk.sections, parsedSection {
+                section:  section,
+                reported: len(parser.chunk.parsed),
+        })
+}
+
+/* distribute gives each diagnostic that the lexer reported to the chunk that
+ * it is in.
+ */
+func (source *sourceFile) distribute (diagnostics []diagnostic.Diagnostic) {
+        for _, mistake := range diagnostics {
+                where := &source.header
+                for _, chunk := range source.chunks {
+                        if mistake.Row >= chunk.startRow { where = chunk }
+                }
+                where.lexed = append(where.lexed, mistake)
+        }
+}
+
+/* merge adds everything that was found in a file to the module, and reports its
+ * diagnostics. Duplicate sections are reported right where they would have been
+ * if the file had been parsed directly into the module.
+ */
+func (parser *Parser) merge (source *sourceFile) {
+        for _, mistake := range source.scanned {
+                parser.sink.Report(mistake)
+        }
+
+        // warnings that the user has allowed in this file should be dropped
+        sink := &diagnostic.Suppressor { Sink: parser.sink }
+        sink.Add(source.suppressions...)
+
+        chunks := append([]*chunk { &source.header }, source.chunks...)
+        for _, chunk := range chunks {
+                for _, mistake := range chunk.lexed {
+                        sink.Report(mistake)
+                }
+        }
+
+        for _, chunk := range chunks {
+                reported := 0
+                for _, parsed := range chunk.sections {
+                        for ; reported < parsed.reported; reported ++ {
+                                sink.Report(chunk.parsed[reported])
+                        }
+
+                        switch section := parsed.section.(type) {
+                        case *Data:
+                                err := parser.module.addData(section)
+                                parser.printDuplicate(section.where, err)
+                                break
+                        case *Typedef:
+                                err := parser.module.addTypedef(section)
+                                parser.printDuplicate(section.where, err)
+                                break
+                        case *Function:
+                                err := parser.module.addFunction(section)
+                                parser.printDuplicate(section.where, err)
+                                break
+                        }
+                }
+
+                for ; reported < len(chunk.parsed); reported ++ {
+                        sink.Report(chunk.parsed[reported])
+                }
+        }
+
+        // later files take precedence over earlier ones, just like later
+        // lines take precedence over earlier ones within a file
+        metadata := source.metadata
+        if metadata.author  != "" { parser.module.author  = metadata.author  }
+        if metadata.license != "" { parser.module.license = metadata.license }
+        parser.module.imports = append (
+                parser.module.imports,
+                metadata.imports...)
+}
@@ -0,0 +1,70 @@
+package parser_test
+
+import "fmt"
+import "bytes"
+import "strings"
+import "testing"
+import "github.com/sashakoshka/arf/parser"
+import "github.com/sashakoshka/arf/diagnostic"
+
+/* TestParseDeterministic parses a module with many files over and over again,
+ * and checks that the sections and diagnostics always come out the same, no
+ * matter which order the files finish parsing in. Run it with -race to check
+ * that the files do not share anything while they are being parsed.
+ */
+func TestParseDeterministic (test *testing.T) {
+        sources := map[string] []byte { }
+        for index := 0; index < 16; index ++ {
+                name := fmt.Sprintf("file%02d.arf", index)
+                sources[name] = []byte (fmt.Sprintf (
+`:arf
+module several
+author "author %d"
+---
+
+data rr value%d:Int %d
+
+# every file defines this, so all but the first are duplicates
+data rr shared:Int %d
+
+func rr function%d
+        < status:Int
+        ---
+        [io.println "unclosed
+`, index, index, index, index, index))
+        }
+
+        var expected string
+        for attempt := 0; attempt < 32; attempt ++ {
+                output := &bytes.Buffer { }
+                sink   := &diagnostic.JSONWriter { Output: output }
+
+                module, err := parser.ParseSources (
+                        "several", sources, false, sink)
+                if err != nil { test.Fatal(err) }
+
+                err = module.Print(output)
+                if err != nil { test.Fatal(err) }
+
+                if attempt == 0 {
+                        expected = output.String()
+                        checkContains(test, expected, diagnostic.CodeUnclosed)
+                        checkContains (
+                                test, expected,
+                                diagnostic.CodeDuplicateSection)
+                        continue
+                }
+                if output.String() != expected {
+                        test.Fatalf (
+                                "attempt %d differs from the first:\n%s\n" +
+                                "expected:\n%s",
+                                attempt, output.String(), expected)
+                }
+        }
+}
+
+func checkContains (test *testing.T, output string, code string) {
+        if !strings.Contains(output, "\"" + code + "\"") {
+                test.Fatalf("%s was not reported:\n%s", code, output)
+        }
+}
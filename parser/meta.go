@@ -1,18 +1,33 @@
 package parser
 
 import "github.com/sashakoshka/arf/lexer"
+import "github.com/sashakoshka/arf/suggest"
+import "github.com/sashakoshka/arf/diagnostic"
+
+/* headerDirectives lists every directive that can be used in the metadata
+ * header of a file.
+ */
+var headerDirectives = []string { "module", "author", "license", "require" }
 
 /* parseMeta parses the metadata header of an arf file. This contains the module
  * name, and other miscellaneous fields such as author and license. Returns an
- * error if the file cannot be parsed further.
+ * error if the file cannot be parsed further. A line that cannot be parsed is
+ * reported and skipped, along with any indented lines after it, since they are
+ * most likely meant to be a part of it.
  */
 func (parser *Parser) parseMeta () (err error) {
-        for {
+        skipping := false
+        for ; !parser.endOfFile(); parser.nextLine() {
                 if parser.line.Indent != 0 {
-                        parser.printError(0, errBadIndent)
-                        return nil
+                        if !skipping {
+                                parser.printError (
+                                        diagnostic.CodeBadIndent, 0,
+                                        errBadIndent)
+                        }
+                        continue
                 }
 
+                skipping = true
                 if !parser.expect (
                         lexer.TokenKindName,
                         lexer.TokenKindSeparator,
@@ -23,7 +38,8 @@ func (parser *Parser) parseMeta () (err error) {
                         return
                 }
 
-                key := parser.token.Value
+                key := parser.token.StringValue
+                keyColumn := parser.token.Column
 
                 parser.nextToken()
                 if !parser.expect (
@@ -48,18 +64,19 @@ func (parser *Parser) parseMeta () (err error) {
                         )
                         break
                 default:
-                        parser.printWarning(0, "uknown header directive")
+                        parser.sink.Report (parser.diagnose (
+                                diagnostic.SeverityWarning,
+                                diagnostic.CodeUnknownDirective, keyColumn,
+                                "unknown header directive \"" + key + "\"",
+                        ).WithNotes (
+                                suggest.DidYouMean (
+                                        key, headerDirectives...)...))
                 }
 
                 // the rest of the line should be empty
                 parser.nextToken()
-                parser.expect()
-                
-                done := parser.nextLine()
-                if done {
-                        return errSurpriseEOF
-                }
+                skipping = !parser.expect()
         }
-        
-        return
+
+        return errSurpriseEOF
 }
@@ -1,12 +1,31 @@
 package parser
 
-import "errors"
 import "github.com/sashakoshka/arf/lineFile"
+import "github.com/sashakoshka/arf/diagnostic"
 
+/* Position is the span of source code that a part of the AST came from. It
+ * starts at row and column, and ends right before endRow and endColumn. The
+ * offsets are the same span, in bytes from the start of the file.
+ */
 type Position struct {
         row    int
         column int
         file   *lineFile.LineFile
+
+        endRow    int
+        endColumn int
+        offset    int
+        endOffset int
+}
+
+/* Comments holds the comments attached to a section, member, argument, or
+ * statement. The leading comments are the whole-line comments directly above it,
+ * and the trailing comment is the one at the end of the line it starts on.
+ * Comments include the # that starts them.
+ */
+type Comments struct {
+        leading  []string
+        trailing string
 }
 
 type Module struct {
@@ -21,10 +40,17 @@ type Module struct {
         functions map[string] *Function
         typedefs  map[string] *Typedef
         datas     map[string] *Data
+
+        // sources holds what was found in each file of the module, in the
+        // order they were merged in. It is used to reparse the module when
+        // one of them changes.
+        sources []*sourceFile
+        skim    bool
 }
 
 type Function struct {
-        where Position
+        where    Position
+        comments Comments
 
         isMember bool
         self     string
@@ -71,7 +97,8 @@ type Block struct {
 }
 
 type Statement struct {
-        where Position
+        where    Position
+        comments Comments
         
         command   Identifier
         arguments []Argument
@@ -116,23 +143,39 @@ type Argument struct {
         integerValue       uint64
         signedIntegerValue int64
         floatValue         float64
+
+        // suffix is the type suffix that a number was written with, such as
+        // u8 or f32. if there is none, it is empty.
+        suffix string
 }
 
 type Variable struct {
-        where Position
+        where    Position
+        comments Comments
 
         name  string
         what  Type
         value []interface { }
+
+        // suffixes holds the type suffix that each default value was written
+        // with, or an empty string if it had none. values that were not
+        // parsed from a file have no suffixes, so this is nil.
+        suffixes []string
 }
 
 type Data struct {
-        where Position
+        where    Position
+        comments Comments
         
         name  string
         what  Type
         value []interface { }
 
+        // suffixes holds the type suffix that each default value was written
+        // with, or an empty string if it had none. values that were not
+        // parsed from a file have no suffixes, so this is nil.
+        suffixes []string
+
         modeInternal Mode
         modeExternal Mode
 
@@ -148,7 +191,8 @@ const (
 )
 
 type Typedef struct {
-        where Position
+        where    Position
+        comments Comments
         
         name     string
         inherits Type
@@ -159,14 +203,30 @@ type Typedef struct {
         modeExternal Mode
 }
 
+/* errDuplicate is returned when a section cannot be added to a module because
+ * there is already a section of the same name in it.
+ */
+type errDuplicate struct {
+        kind     string
+        name     string
+        previous Position
+}
+
+func (err *errDuplicate) Error () (description string) {
+        return err.kind + " section " + err.name + " already exists"
+}
+
 /* addData adds a data section to a module
  */
 func (module *Module) addData (data *Data) (err error) {
         if data == nil { return }
-        _, exists := module.datas[data.name]
+        existing, exists := module.datas[data.name]
         if exists {
-                return errors.New (
-                        "data section " + data.name + "already exists")
+                return &errDuplicate {
+                        kind:     "data",
+                        name:     data.name,
+                        previous: existing.where,
+                }
         }
 
         module.datas[data.name] = data
@@ -190,10 +250,13 @@ func (block *Block) addVariable (variable *Variable) (worked bool) {
  */
 func (module *Module) addTypedef (typedef *Typedef) (err error) {
         if typedef == nil { return }
-        _, exists := module.typedefs[typedef.name]
+        existing, exists := module.typedefs[typedef.name]
         if exists {
-                return errors.New (
-                        "data section " + typedef.name + " already exists")
+                return &errDuplicate {
+                        kind:     "type",
+                        name:     typedef.name,
+                        previous: existing.where,
+                }
         }
 
         module.typedefs[typedef.name] = typedef
@@ -204,26 +267,114 @@ func (module *Module) addTypedef (typedef *Typedef) (err error) {
  */
 func (module *Module) addFunction (function *Function) (err error) {
         if function == nil { return }
-        _, exists := module.functions[function.name]
+        existing, exists := module.functions[function.name]
         if exists {
-                return errors.New (
-                        "data section " + function.name + " already exists")
+                return &errDuplicate {
+                        kind:     "func",
+                        name:     function.name,
+                        previous: existing.where,
+                }
         }
 
         module.functions[function.name] = function
         return nil
 }
 
-func (where *Position) PrintWarning (cause ...interface {}) {
-        where.file.PrintWarning(where.column, where.row, cause...)
+/* GetRow returns the row that the position points to. Rows start at zero, so
+ * add one when presenting it to a user or emitting a #line directive.
+ */
+func (where Position) GetRow () (row int) {
+        return where.row
 }
 
-func (where *Position) PrintError (cause ...interface {}) {
-        where.file.PrintError(where.column, where.row, cause...)
+/* GetColumn returns the column that the position points to, starting at zero.
+ */
+func (where Position) GetColumn () (column int) {
+        return where.column
+}
+
+/* GetFile returns the file that the position is in.
+ */
+func (where Position) GetFile () (file *lineFile.LineFile) {
+        return where.file
 }
 
-func (where *Position) PrintFatal (err error) {
-        where.file.PrintFatal(err)
+/* GetEndRow returns the row that the position ends on.
+ */
+func (where Position) GetEndRow () (row int) {
+        return where.endRow
+}
+
+/* GetEndColumn returns the column that the position ends before.
+ */
+func (where Position) GetEndColumn () (column int) {
+        return where.endColumn
+}
+
+/* GetOffset returns the byte offset from the start of the file that the
+ * position starts at.
+ */
+func (where Position) GetOffset () (offset int) {
+        return where.offset
+}
+
+/* GetEndOffset returns the byte offset from the start of the file that the
+ * position ends before.
+ */
+func (where Position) GetEndOffset () (offset int) {
+        return where.endOffset
+}
+
+/* diagnose creates a diagnostic that spans the position.
+ */
+func (where Position) diagnose (
+        severity diagnostic.Severity,
+        code     string,
+        cause    ...interface {},
+) (
+        mistake diagnostic.Diagnostic,
+) {
+        mistake = diagnostic.At (
+                severity, code, where.file,
+                where.row, where.column, cause...)
+
+        if where.endRow >= where.row {
+                mistake.EndRow    = where.endRow
+                mistake.EndColumn = where.endColumn
+        }
+        return
+}
+
+/* ReportWarning reports a warning at this position to sink.
+ */
+func (where Position) ReportWarning (
+        sink  diagnostic.Sink,
+        code  string,
+        cause ...interface {},
+) {
+        sink.Report(where.diagnose(diagnostic.SeverityWarning, code, cause...))
+}
+
+/* ReportError reports an error at this position to sink.
+ */
+func (where Position) ReportError (
+        sink  diagnostic.Sink,
+        code  string,
+        cause ...interface {},
+) {
+        sink.Report(where.diagnose(diagnostic.SeverityError, code, cause...))
+}
+
+/* ReportFatal reports a fatal error concerning the file this position is in to
+ * sink.
+ */
+func (where Position) ReportFatal (
+        sink diagnostic.Sink,
+        code string,
+        err  error,
+) {
+        sink.Report (diagnostic.InFile (
+                diagnostic.SeverityFatal, code, where.file, err))
 }
 
 /* GetMetadata returns the metadata fields of the module
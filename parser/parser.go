@@ -1,8 +1,11 @@
 package parser
 
+import "io"
 import "os"
 import "fmt"
 import "path"
+import "sort"
+import "bytes"
 import "bufio"
 import "errors"
 import "strings"
@@ -10,6 +13,7 @@ import "io/ioutil"
 import "github.com/sashakoshka/arf/lexer"
 import "github.com/sashakoshka/arf/lineFile"
 import "github.com/sashakoshka/arf/validate"
+import "github.com/sashakoshka/arf/diagnostic"
 
 var (
         errEmptyModule   = errors.New("there are no files in this module")
@@ -19,8 +23,19 @@ var (
         errSurpriseEOF   = errors.New("file terminated unexpectedly")
         errSurpriseEOL   = errors.New("line terminated unexpectedly")
         errNotArf        = errors.New("not an arf file, expected :arf")
+        errWrongModule   = errors.New("file belongs to a different module")
+
+        // errSkipped is returned when something could not be parsed and was
+        // skipped over. The mistake has already been reported, so the caller
+        // can carry on from where the parser was left.
+        errSkipped       = errors.New("skipped over a mistake")
 )
 
+/* Verbose determines whether or not Parse reports its progress as it searches
+ * for and parses files. Progress is written to stderr.
+ */
+var Verbose = false
+
 /* Parser is a magic machine that turns a path into a parsed AST. Neato!
  */
 type Parser struct {
@@ -33,93 +48,240 @@ type Parser struct {
 
         token      *lexer.Token
         tokenIndex int
+
+        // previous is the last token that was moved past. It is where the
+        // span of something that has just been parsed ends.
+        previous   *lexer.Token
         
         module     *Module
 
-        warnCount  int
-        errorCount int
+        sink       diagnostic.Sink
+
+        // source is where everything found in the file being parsed is
+        // kept until it is merged into the module, and chunk is the part of
+        // it that is currently being parsed.
+        source     *sourceFile
+        chunk      *chunk
 }
 
 /* Parse takes in a module path, and returns a Module. The file at the end of
  * the path should not, as it is a virtual concept that is searched for in the
  * path's base directory. All files with a matching module feild are parsed into
  * the module that gets returned. It's like golang packages, except we are
- * calling them modules because we aren't insane.
+ * calling them modules because we aren't insane. Any mistakes found along the
+ * way are reported to sink. If the module path is "-", a module consisting of
+ * a single file is read from standard input.
  */
 func Parse (
         modulePath string,
         skim bool,
+        sink diagnostic.Sink,
 ) (
-        module     *Module,
-        warnCount  int,
-        errorCount int,
-        err        error,
+        module *Module,
+        err    error,
 ) {
+        if modulePath == "-" { return parseStandardInput(skim, sink) }
+        
         moduleDir  := path.Dir(modulePath)
         moduleBase := path.Base(modulePath)
-        fmt.Println("...", "parsing module \"" + moduleBase + "\"")
-
-        parser := &Parser {
-                directory: moduleDir,
-                module:    &Module {
-                        name:      moduleBase,
-                        path:      moduleDir + moduleBase,
-                        functions: make(map[string] *Function),
-                        typedefs:  make(map[string] *Typedef),
-                        datas:     make(map[string] *Data),
-                },
-        }
-
-        if !validate.ValidateName(moduleBase) {
-                err = errors.New (
-                        "\"" + moduleBase + "\" is not a valid module name")
-                parser.printGeneralFatal(err)
-                return nil, 0, 1, err
-        }
+        
+        parser, err := newParser(moduleBase, moduleDir, sink)
+        if err != nil { return nil, err }
 
         candidates, err := ioutil.ReadDir(parser.directory)
         if err != nil {
-                parser.printFatal(err)
-                return parser.module, 0, 1, err
+                parser.printGeneralFatal(diagnostic.CodeUnreadable, err)
+                return parser.module, err
         }
 
-        foundFile := false
+        var sources []fileSource
         for _, candidate := range candidates {
                 if candidate.IsDir() { continue }
                 filePath := moduleDir + "/" + candidate.Name()
-                if getModuleName(filePath) != parser.module.name { continue }
+                if GetModuleName(filePath) != parser.module.name { continue }
 
-                fmt.Println("(i)", "found file", filePath)
-                foundFile = true
+                if Verbose {
+                        fmt.Fprintln(os.Stderr, "(i)", "found file", filePath)
+                }
 
-                // attempt to parse the file. if any part fails, go on to the
-                // next one.
-                err = parser.parseFile(filePath, skim)
-                if err != nil { continue }
+                moduleName := parser.module.name
+                sources = append(sources, func () (*lineFile.LineFile, error) {
+                        return lineFile.Open(filePath, moduleName)
+                })
         }
 
-        if !foundFile {
-                parser.printGeneralFatal(errEmptyModule)
-                return nil, 0, 1, errEmptyModule
+        if len(sources) == 0 {
+                parser.printGeneralFatal (
+                        diagnostic.CodeEmptyModule,
+                        errEmptyModule)
+                return nil, errEmptyModule
+        }
+
+        // if any file fails to parse, the others still get parsed
+        parser.parseFiles(sources, skim)
+
+        if Verbose { fmt.Fprintln(os.Stderr, ".//", "module parsed") }
+        return parser.module, nil
+}
+
+/* ParseSources is like Parse, but instead of searching for files on disk, it
+ * parses a module made up of files that are already in memory. Sources maps
+ * the name of each file to its content. The names are only used to refer to
+ * the files in diagnostics, and files are parsed in order of name. Every file
+ * must belong to the module.
+ */
+func ParseSources (
+        moduleName string,
+        sources    map[string] []byte,
+        skim       bool,
+        sink       diagnostic.Sink,
+) (
+        module *Module,
+        err    error,
+) {
+        parser, err := newParser(moduleName, "", sink)
+        if err != nil { return nil, err }
+
+        if len(sources) == 0 {
+                parser.printGeneralFatal (
+                        diagnostic.CodeEmptyModule,
+                        errEmptyModule)
+                return nil, errEmptyModule
+        }
+        
+        names := make([]string, 0, len(sources))
+        for name := range sources {
+                names = append(names, name)
+        }
+        sort.Strings(names)
+
+        fileSources := make([]fileSource, len(names))
+        for index, name := range names {
+                name    := name
+                content := sources[name]
+                fileSources[index] = func () (*lineFile.LineFile, error) {
+                        file, err := lineFile.FromBytes (
+                                name, moduleName, content)
+                        if err != nil { return file, err }
+                        
+                        if ReadModuleName(bytes.NewReader(content)) !=
+                                moduleName {
+                                return file, errWrongModule
+                        }
+                        return file, nil
+                }
         }
+        parser.parseFiles(fileSources, skim)
 
-        fmt.Println(".//", "module parsed")
-        return parser.module, parser.warnCount, parser.errorCount, nil
+        if Verbose { fmt.Fprintln(os.Stderr, ".//", "module parsed") }
+        return parser.module, nil
+}
+
+/* parseStandardInput parses a module consisting of a single file that is read
+ * from standard input.
+ */
+func parseStandardInput (
+        skim bool,
+        sink diagnostic.Sink,
+) (
+        module *Module,
+        err    error,
+) {
+        return ParseReader("-", os.Stdin, skim, sink)
 }
 
-/* parseFile parses a specific file into the module.
+/* ParseReader parses a module consisting of a single file that is read from
+ * reader. The name of the module is taken from the file itself, and the file
+ * name is only used to refer to the file in diagnostics.
  */
-func (parser *Parser) parseFile (filePath string, skim bool) (err error) {
-        // open file safely
-        parser.file, err = lineFile.Open(filePath, parser.module.name)
+func ParseReader (
+        fileName string,
+        reader   io.Reader,
+        skim     bool,
+        sink     diagnostic.Sink,
+) (
+        module *Module,
+        err    error,
+) {
+        content, err := ioutil.ReadAll(reader)
         if err != nil {
-                parser.printFatal(err)
-                return
+                sink.Report (diagnostic.InModule (
+                        diagnostic.SeverityFatal, diagnostic.CodeUnreadable,
+                        fileName, err))
+                return nil, err
         }
+
+        moduleName := ReadModuleName(bytes.NewReader(content))
+        if moduleName == "" {
+                sink.Report (diagnostic.InModule (
+                        diagnostic.SeverityFatal, diagnostic.CodeUnreadable,
+                        fileName, errNotArf))
+                return nil, errNotArf
+        }
+
+        return ParseSources (
+                moduleName,
+                map[string] []byte { fileName: content },
+                skim, sink)
+}
+
+/* newParser creates a parser for the module with the specified name, making
+ * sure that the name is valid.
+ */
+func newParser (
+        moduleName string,
+        moduleDir  string,
+        sink       diagnostic.Sink,
+) (
+        parser *Parser,
+        err    error,
+) {
+        if Verbose {
+                fmt.Fprintln (
+                        os.Stderr,
+                        "...", "parsing module \"" + moduleName + "\"")
+        }
+
+        parser = &Parser {
+                directory: moduleDir,
+                sink:      sink,
+                module:    &Module {
+                        name:      moduleName,
+                        path:      moduleDir + moduleName,
+                        functions: make(map[string] *Function),
+                        typedefs:  make(map[string] *Typedef),
+                        datas:     make(map[string] *Data),
+                },
+        }
+
+        if !validate.ValidateName(moduleName) {
+                err = errors.New (
+                        "\"" + moduleName + "\" is not a valid module name")
+                parser.printGeneralFatal(diagnostic.CodeInvalidModuleName, err)
+                return nil, err
+        }
+
+        return
+}
+
+/* parseFile parses a specific file into the source file that the parser is
+ * currently filling in.
+ */
+func (parser *Parser) parseFile (
+        file *lineFile.LineFile,
+        skim bool,
+) (
+        err error,
+) {
+        parser.file = file
+
+        scanned := &diagnostic.Collector { }
+        parser.source.suppressions = lexer.ScanSuppressions(file, scanned)
+        parser.source.scanned = scanned.GetDiagnostics()
         
-        lines, nWarn, nError, err := lexer.Tokenize (
-                parser.file,
-                parser.module.name)
+        lexed := &diagnostic.Collector { }
+        lines, err := lexer.Tokenize(parser.file, lexed)
+        parser.chunk.lexed = lexed.GetDiagnostics()
 
         if err != nil { return err }
         if len(lines) == 0 {
@@ -128,8 +290,7 @@ func (parser *Parser) parseFile (filePath string, skim bool) (err error) {
         }
 
         parser.lines = lines
-        parser.warnCount  += nWarn
-        parser.errorCount += nError
+        parser.previous = nil
         
         parser.lineIndex = 0
         parser.line = parser.lines[parser.lineIndex]
@@ -144,28 +305,43 @@ func (parser *Parser) parseFile (filePath string, skim bool) (err error) {
         }
 
         // parse body
+        parser.chunk.endRow = file.GetLength()
         err = parser.parseBody(skim)
         if err != nil {
                 parser.printFatal(err)
                 return err
         }
 
+        // the lexer's diagnostics were all put in the header, since the
+        // chunks did not exist yet
+        header := parser.source.header.lexed
+        parser.source.header.lexed = nil
+        parser.source.distribute(header)
+
+        parser.source.broken = false
         return nil
 }
 
-/* getModuleName takes in a file path (an actual one!) and returns the module
+/* GetModuleName takes in a file path (an actual one!) and returns the module
  * name that the file is a part of. If the file is not an arf file, it returns
  * an empty string.
  */
-func getModuleName (filePath string) (name string) {
+func GetModuleName (filePath string) (name string) {
         // open file
         if path.Ext(filePath) != ".arf" { return "" }
         file, err := os.Open(filePath)
-        defer file.Close()
         if err != nil { return "" }
+        defer file.Close()
+
+        return ReadModuleName(file)
+}
 
+/* ReadModuleName is like GetModuleName, but it reads the file from reader. The
+ * file can come from anywhere, so its name is not checked.
+ */
+func ReadModuleName (reader io.Reader) (name string) {
         // look for magic bytes
-        scanner := bufio.NewScanner(file)
+        scanner := bufio.NewScanner(reader)
         scanned := scanner.Scan()
         if !scanned                 { return "" }
         if scanner.Err()  != nil    { return "" }
@@ -219,6 +395,7 @@ func (parser *Parser) expect (kinds ...lexer.TokenKind) (match bool) {
                         return true
                 } else {
                         parser.printError (
+                                diagnostic.CodeUnexpectedToken,
                                 parser.token.Column,
                                 "unexpected token, expected end of line")
                         return false
@@ -261,7 +438,7 @@ func (parser *Parser) expect (kinds ...lexer.TokenKind) (match bool) {
                 errText += kinds[len(kinds) - 1].ToString()
         }
 
-        parser.printError(errColumn, errText)
+        parser.printError(diagnostic.CodeUnexpectedToken, errColumn, errText)
         return false
 }
 
@@ -270,6 +447,7 @@ func (parser *Parser) expect (kinds ...lexer.TokenKind) (match bool) {
  */
 func (parser *Parser) nextToken () (done bool) {
         if parser.endOfFile() { return true }
+        if !parser.endOfLine() { parser.previous = parser.token }
 
         parser.tokenIndex ++
         if parser.endOfLine() {
@@ -297,28 +475,70 @@ func (parser *Parser) getCurrentRealRow () (row int) {
         return line.Row
 }
 
-func (parser *Parser) printWarning (column int, cause ...interface {}) {
-        parser.warnCount ++
-        parser.file.PrintWarning(column, parser.getCurrentRealRow(), cause...)
+func (parser *Parser) printWarning (
+        code   string,
+        column int,
+        cause  ...interface {},
+) {
+        parser.sink.Report (parser.diagnose (
+                diagnostic.SeverityWarning, code, column, cause...))
 }
 
-func (parser *Parser) printError (column int, cause ...interface {}) {
-        parser.errorCount ++
-        parser.file.PrintError(column, parser.getCurrentRealRow(), cause...)
+func (parser *Parser) printError (
+        code   string,
+        column int,
+        cause  ...interface {},
+) {
+        parser.sink.Report (parser.diagnose (
+                diagnostic.SeverityError, code, column, cause...))
 }
 
+/* diagnose creates a diagnostic pointing to the specified column of the current
+ * line, without reporting it. This is useful for adding notes or labels to a
+ * diagnostic before reporting it.
+ */
+func (parser *Parser) diagnose (
+        severity diagnostic.Severity,
+        code     string,
+        column   int,
+        cause    ...interface {},
+) (
+        mistake diagnostic.Diagnostic,
+) {
+        mistake = diagnostic.At (
+                severity, code, parser.file,
+                parser.getCurrentRealRow(), column, cause...)
+
+        // if the diagnostic points to the current token, span all of it
+        onToken :=
+                !parser.endOfFile() &&
+                !parser.endOfLine() &&
+                parser.token.Column == column
+        if onToken {
+                mistake.EndRow    = parser.token.EndRow
+                mistake.EndColumn = parser.token.EndColumn
+        }
+        return
+}
+
+/* printFatal reports an error that stops the current file from being parsed
+ * any further.
+ */
 func (parser *Parser) printFatal (err error) {
-        parser.errorCount ++
-        parser.file.PrintFatal(err)
+        code := diagnostic.CodeUnreadable
+        switch err {
+        case errEmptyFile:   code = diagnostic.CodeEmptyFile
+        case errSurpriseEOF: code = diagnostic.CodeSurpriseEOF
+        case errWrongModule: code = diagnostic.CodeWrongModule
+        }
+        
+        parser.sink.Report (diagnostic.InFile (
+                diagnostic.SeverityFatal, code, parser.file, err))
 }
 
-func (parser *Parser) printGeneralFatal (err error) {
-        parser.errorCount ++
-        fmt.Println (
-                "\033[31mXXX\033[0m",
-                "\033[90min\033[0m",
-                parser.module.name)
-        fmt.Println("   ", err)
+func (parser *Parser) printGeneralFatal (code string, err error) {
+        parser.sink.Report (diagnostic.InModule (
+                diagnostic.SeverityFatal, code, parser.module.name, err))
 }
 
 /* embedPosition
@@ -326,9 +546,51 @@ func (parser *Parser) printGeneralFatal (err error) {
  * can be embedded into a struct.
  */
 func (parser *Parser) embedPosition () (position Position) {
-        return Position {
+        position = Position {
                 column: parser.token.Column,
                 row:    parser.line.Row,
                 file:   parser.file,
         }
+
+        if parser.endOfLine() {
+                position.column = parser.line.EndColumn
+        }
+        
+        position.offset    = parser.file.GetOffset(position.row, position.column)
+        position.endRow    = position.row
+        position.endColumn = position.column
+        position.endOffset = position.offset
+        return
+}
+
+/* closePosition ends the span of a position at the last token that the parser
+ * moved past. It should be called once whatever the position belongs to has
+ * been parsed.
+ */
+func (parser *Parser) closePosition (position *Position) {
+        previous := parser.previous
+        if previous == nil { return }
+        
+        // if nothing was moved past since the position was embedded, it is
+        // empty
+        if previous.EndOffset <= position.offset { return }
+        
+        position.endRow    = previous.EndRow
+        position.endColumn = previous.EndColumn
+        position.endOffset = previous.EndOffset
+}
+
+/* embedComments returns the comments attached to the current line, so that
+ * they can be embedded into whatever starts on it.
+ */
+func (parser *Parser) embedComments () (comments Comments) {
+        for _, comment := range parser.line.Comments {
+                comments.leading = append(comments.leading, comment.Text)
+        }
+        
+        if parser.line.Trailing != nil {
+                comments.trailing = parser.line.Trailing.Text
+        }
+        
+        return
 }
@@ -0,0 +1,540 @@
+package parser
+
+import "io"
+import "fmt"
+import "errors"
+import "strings"
+import "unicode"
+import "strconv"
+import "unicode/utf8"
+
+/* printer writes out a module as arf code. It keeps track of which variables
+ * have been declared, since a variable is declared where it is first used
+ * instead of on a line of its own.
+ */
+type printer struct {
+        strings.Builder
+        declared map[*Variable] bool
+
+        // column is how many runes have been written on the current line
+        column int
+}
+
+/* escapeCodes maps the runes that have single letter escape codes to them.
+ */
+var escapeCodes = map[rune] rune {
+        '\a': 'a',
+        '\b': 'b',
+        '\f': 'f',
+        '\n': 'n',
+        '\r': 'r',
+        '\t': 't',
+        '\v': 'v',
+}
+
+/* Print writes the module out as arf code, formatted the way arf code is meant
+ * to be written. Parsing what it writes gives back the same module, apart from
+ * the positions of things in it. If the module was parsed from several files,
+ * they are all written out as one. Sections are written in the order that they
+ * appear in the source code, and sections that were built from scratch are
+ * written after them, sorted by kind and then name. An error is returned if
+ * the module cannot be written out as arf code, in which case nothing is
+ * written.
+ */
+func (module *Module) Print (output io.Writer) (err error) {
+        printer := &printer { declared: make(map[*Variable] bool) }
+        err = printer.printModule(module)
+        if err != nil { return }
+
+        _, err = io.WriteString(output, printer.String())
+        return
+}
+
+func (printer *printer) printModule (module *Module) (err error) {
+        printer.WriteString(":arf\n")
+        printer.WriteString("module " + module.name + "\n")
+        if module.author != "" {
+                printer.WriteString (
+                        "author " + quote(module.author, '"') + "\n")
+        }
+        if module.license != "" {
+                printer.WriteString (
+                        "license " + quote(module.license, '"') + "\n")
+        }
+        for _, path := range module.imports {
+                printer.WriteString("require " + quote(path, '"') + "\n")
+        }
+        printer.WriteString("---\n")
+
+        for _, section := range module.getSectionsInOrder() {
+                printer.WriteString("\n")
+                switch section := section.(type) {
+                case *Typedef:
+                        err = printer.printTypedef(section)
+                        break
+                case *Data:
+                        printer.printComments(&section.comments, 0)
+                        printer.WriteString("data ")
+                        err = printer.printData(section, 0)
+                        break
+                case *Function:
+                        err = printer.printFunction(section)
+                        break
+                }
+                if err != nil { return }
+        }
+        return
+}
+
+func (printer *printer) printTypedef (typedef *Typedef) (err error) {
+        printer.printComments(&typedef.comments, 0)
+        printer.WriteString (
+                "type " +
+                encodePermission(typedef.modeInternal, typedef.modeExternal) +
+                " " + typedef.name + ":")
+        err = printer.printType(&typedef.inherits)
+        if err != nil { return }
+        printer.printTrailing(&typedef.comments)
+
+        for _, member := range typedef.members {
+                printer.printComments(&member.comments, 1)
+                printer.printIndent(1)
+                err = printer.printData(member, 1)
+                if err != nil { return }
+        }
+        return
+}
+
+/* printData prints the permission, name, type, and default values of a data
+ * section or a member of a type definition, which is indented by indent.
+ */
+func (printer *printer) printData (data *Data, indent int) (err error) {
+        printer.WriteString (
+                encodePermission(data.modeInternal, data.modeExternal) +
+                " " + data.name + ":")
+        err = printer.printType(&data.what)
+        if err != nil { return }
+        err = printer.printValues (
+                data.value, data.suffixes, indent,
+                data.comments.trailing == "")
+        if err != nil { return }
+        printer.printTrailing(&data.comments)
+        return
+}
+
+func (printer *printer) printFunction (function *Function) (err error) {
+        printer.printComments(&function.comments, 0)
+        printer.WriteString (
+                "func " +
+                encodePermission(function.modeInternal, function.modeExternal) +
+                " " + function.name)
+        printer.printTrailing(&function.comments)
+
+        root := function.root
+        if root == nil { root = NewBlock() }
+
+        if function.isMember {
+                err = printer.printArgument("@", root, function.self)
+                if err != nil { return }
+        }
+        for _, name := range function.inputs {
+                err = printer.printArgument(">", root, name)
+                if err != nil { return }
+        }
+        for _, name := range function.outputs {
+                err = printer.printArgument("<", root, name)
+                if err != nil { return }
+        }
+
+        printer.printIndent(1)
+        printer.WriteString("---\n")
+
+        if function.external {
+                printer.printIndent(1)
+                printer.WriteString("external\n")
+                return
+        }
+
+        // the first statement of a function cannot be a call to something
+        // called external, since that would make the function external
+        if len(root.items) > 0 {
+                first := root.items[0].statement
+                isExternal :=
+                        first != nil && !first.external &&
+                        len(first.command.trail) == 1 &&
+                        first.command.trail[0] == "external"
+                if isExternal {
+                        return errors.New (
+                                "the first statement of function " +
+                                function.name + " cannot call external")
+                }
+        }
+
+        return printer.printBlock(root, 1)
+}
+
+/* printArgument prints the line that declares an argument of a function, which
+ * is a variable in its root block.
+ */
+func (printer *printer) printArgument (
+        symbol string,
+        root   *Block,
+        name   string,
+) (
+        err error,
+) {
+        variable, exists := root.variables[name]
+        if !exists {
+                return errors.New("argument " + name + " has no variable")
+        }
+        printer.declared[variable] = true
+
+        printer.printComments(&variable.comments, 1)
+        printer.printIndent(1)
+        printer.WriteString(symbol + " " + name + ":")
+        err = printer.printType(&variable.what)
+        if err != nil { return }
+        err = printer.printValues (
+                variable.value, variable.suffixes, 1,
+                variable.comments.trailing == "")
+        if err != nil { return }
+        printer.printTrailing(&variable.comments)
+        return
+}
+
+/* printBlock prints each statement in a block on a line of its own, indented
+ * by indent. Blocks inside of it are indented one level further.
+ */
+func (printer *printer) printBlock (block *Block, indent int) (err error) {
+        for index, item := range block.items {
+                if item.block != nil {
+                        if len(item.block.items) == 0 {
+                                return errors.New("blocks cannot be empty")
+                        }
+                        if index > 0 && block.items[index - 1].block != nil {
+                                return errors.New (
+                                        "a block cannot come directly after " +
+                                        "another")
+                        }
+
+                        err = printer.printBlock(item.block, indent + 1)
+                        if err != nil { return }
+                        continue
+                }
+
+                statement := item.statement
+                if statement == nil { continue }
+
+                printer.printComments(&statement.comments, indent)
+                printer.printIndent(indent)
+                err = printer.printStatement(statement, block, false)
+                if err != nil { return }
+                printer.printTrailing(&statement.comments)
+        }
+
+        // every variable must have been declared somewhere
+        for _, variable := range block.getVariablesInOrder() {
+                variable := variable.(*Variable)
+                if !printer.declared[variable] {
+                        return errors.New (
+                                "variable " + variable.name + " is never " +
+                                "used, so it cannot be declared")
+                }
+        }
+        return
+}
+
+/* printStatement prints a statement. Statements that are arguments of other
+ * statements are nested, and are wrapped in brackets.
+ */
+func (printer *printer) printStatement (
+        statement *Statement,
+        parent    *Block,
+        nested    bool,
+) (
+        err error,
+) {
+        if nested { printer.WriteString("[") }
+
+        if statement.external {
+                printer.WriteString(quote(statement.externalCommand, '"'))
+        } else {
+                if len(statement.command.trail) == 0 { return errEmptyTrail }
+                printer.WriteString(statement.command.ToString())
+        }
+
+        for index := range statement.arguments {
+                printer.WriteString(" ")
+                err = printer.printStatementArgument (
+                        &statement.arguments[index], parent)
+                if err != nil { return }
+        }
+
+        if nested {
+                if len(statement.returnsTo) > 0 {
+                        return errors.New (
+                                "only statements directly in a block can " +
+                                "return to something")
+                }
+                printer.WriteString("]")
+                return
+        }
+
+        if len(statement.returnsTo) > 0 {
+                printer.WriteString(" ->")
+                for _, identifier := range statement.returnsTo {
+                        printer.WriteString(" ")
+                        err = printer.printIdentifier(identifier, parent)
+                        if err != nil { return }
+                }
+        }
+        return
+}
+
+func (printer *printer) printStatementArgument (
+        argument *Argument,
+        parent   *Block,
+) (
+        err error,
+) {
+        switch argument.kind {
+        case ArgumentKindStatement:
+                return printer.printStatement (
+                        argument.statementValue, parent, true)
+        case ArgumentKindIdentifier:
+                return printer.printIdentifier (
+                        argument.identifierValue, parent)
+        case ArgumentKindDereference:
+                dereference := argument.dereferenceValue
+                printer.WriteString("{")
+                err = printer.printStatementArgument (
+                        dereference.dereferences, parent)
+                if err != nil { return }
+                if dereference.offset != 0 {
+                        printer.WriteString (
+                                " " + fmt.Sprint(dereference.offset))
+                }
+                printer.WriteString("}")
+                return
+        case
+                ArgumentKindString,
+                ArgumentKindRune,
+                ArgumentKindInteger,
+                ArgumentKindSignedInteger,
+                ArgumentKindFloat:
+
+                var text string
+                text, err = formatValue (
+                        argument.GetValue(), argument.suffix)
+                printer.WriteString(text)
+                return
+        }
+        return errors.New("argument has no value")
+}
+
+/* printIdentifier prints an identifier that is used in a statement. If it is
+ * where a variable in the parent block is declared, the type of the variable is
+ * printed along with it. Variables that were parsed are declared where they
+ * were declared in the source code, and ones that were built from scratch are
+ * declared where they are first used.
+ */
+func (printer *printer) printIdentifier (
+        identifier *Identifier,
+        parent     *Block,
+) (
+        err error,
+) {
+        if len(identifier.trail) == 0 { return errEmptyTrail }
+        printer.WriteString(identifier.ToString())
+        if len(identifier.trail) > 1 { return }
+
+        variable, exists := parent.variables[identifier.trail[0]]
+        if !exists || printer.declared[variable] { return }
+
+        declaresVariable :=
+                variable.where.file == nil ||
+                variable.where.file   == identifier.where.file &&
+                variable.where.offset == identifier.where.offset
+        if !declaresVariable { return }
+
+        printer.declared[variable] = true
+        printer.WriteString(":")
+        return printer.printType(&variable.what)
+}
+
+/* printType prints a type. Unlike Type.ToString, the count of a pointer is
+ * printed whenever there is one.
+ */
+func (printer *printer) printType (what *Type) (err error) {
+        if what.points != nil {
+                printer.WriteString("{")
+                err = printer.printType(what.points)
+                if err != nil { return }
+                if what.items != 0 {
+                        printer.WriteString(" " + fmt.Sprint(what.items))
+                }
+                printer.WriteString("}")
+        } else {
+                if len(what.name.trail) == 0 { return errEmptyTrail }
+                printer.WriteString(what.name.ToString())
+        }
+
+        if what.mutable { printer.WriteString(":mut") }
+        return
+}
+
+/* printValues prints the default values of a variable, each one with a space
+ * before it and the suffix at the same index after it. If wrap is true, values
+ * that would go past the 80th column are moved on to lines of their own,
+ * indented one level further than indent.
+ */
+func (printer *printer) printValues (
+        values   []interface {},
+        suffixes []string,
+        indent   int,
+        wrap     bool,
+) (
+        err error,
+) {
+        for index, value := range values {
+                var text string
+                text, err = formatValue(value, getSuffix(suffixes, index))
+                if err != nil { return }
+
+                width := printer.column + 1 + utf8.RuneCountInString(text)
+                if wrap && index > 0 && width > 80 {
+                        printer.WriteString("\n")
+                        printer.printIndent(indent + 1)
+                } else {
+                        printer.WriteString(" ")
+                }
+                printer.WriteString(text)
+        }
+        return
+}
+
+/* WriteString writes text, keeping track of which column it ends on.
+ */
+func (printer *printer) WriteString (text string) (length int, err error) {
+        newline := strings.LastIndex(text, "\n")
+        if newline < 0 {
+                printer.column += utf8.RuneCountInString(text)
+        } else {
+                printer.column = utf8.RuneCountInString(text[newline + 1:])
+        }
+        return printer.Builder.WriteString(text)
+}
+
+/* formatValue formats a literal value as arf code, with suffix after it if it
+ * is a number. Signed integers that are not negative and have no suffix are
+ * given one, so that they are still signed when they are read back in.
+ */
+func formatValue (
+        value  interface {},
+        suffix string,
+) (
+        text string,
+        err  error,
+) {
+        value, err = normalizeValue(value)
+        if err != nil { return }
+
+        switch value := value.(type) {
+        case uint64:
+                text = strconv.FormatUint(value, 10) + suffix
+                break
+        case int64:
+                text = strconv.FormatInt(value, 10)
+                if value >= 0 && suffix == "" { suffix = "i64" }
+                text += suffix
+                break
+        case float64:
+                text = strconv.FormatFloat(value, 'g', -1, 64)
+                if !strings.ContainsAny(text, ".e") { text += ".0" }
+                text += suffix
+                break
+        case string:
+                text = quote(value, '"')
+                break
+        case rune:
+                text = quote(string(value), '\'')
+                break
+        }
+        return
+}
+
+/* printComments prints the leading comments of something, each on a line of
+ * its own, indented by indent.
+ */
+func (printer *printer) printComments (comments *Comments, indent int) {
+        for _, comment := range comments.leading {
+                printer.printIndent(indent)
+                printer.WriteString(comment + "\n")
+        }
+}
+
+/* printTrailing prints the trailing comment of something, if there is one, and
+ * ends the line.
+ */
+func (printer *printer) printTrailing (comments *Comments) {
+        if comments.trailing != "" {
+                printer.WriteString(" " + comments.trailing)
+        }
+        printer.WriteString("\n")
+}
+
+/* printIndent prints the specified level of indentation.
+ */
+func (printer *printer) printIndent (level int) {
+        for index := 0; index < level; index ++ {
+                printer.WriteString("        ")
+        }
+}
+
+/* encodePermission turns a pair of modes back into a permission such as rw.
+ */
+func encodePermission (internal Mode, external Mode) (permission string) {
+        return encodeMode(internal) + encodeMode(external)
+}
+
+func encodeMode (mode Mode) (letter string) {
+        switch mode {
+        case ModeRead:  return "r"
+        case ModeWrite: return "w"
+        }
+        return "n"
+}
+
+/* quote wraps text in the specified quotes, escaping anything in it that would
+ * not be read back in the same way.
+ */
+func quote (text string, quotes rune) (quoted string) {
+        builder := strings.Builder { }
+        builder.WriteRune(quotes)
+
+        for _, ch := range text {
+                code, hasCode := escapeCodes[ch]
+                switch {
+                case ch == quotes || ch == '\\':
+                        builder.WriteRune('\\')
+                        builder.WriteRune(ch)
+                        break
+                case hasCode:
+                        builder.WriteRune('\\')
+                        builder.WriteRune(code)
+                        break
+                case !unicode.IsPrint(ch) && ch <= 0xFFFF:
+                        builder.WriteString(fmt.Sprintf("\\u%04x", ch))
+                        break
+                case !unicode.IsPrint(ch):
+                        builder.WriteString(fmt.Sprintf("\\U%08x", ch))
+                        break
+                default:
+                        builder.WriteRune(ch)
+                        break
+                }
+        }
+
+        builder.WriteRune(quotes)
+        return builder.String()
+}
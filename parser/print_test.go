@@ -0,0 +1,139 @@
+package parser_test
+
+import "os"
+import "bytes"
+import "strings"
+import "testing"
+import "unicode/utf8"
+import "path/filepath"
+import "github.com/sashakoshka/arf/parser"
+import "github.com/sashakoshka/arf/diagnostic"
+
+const printSource =
+`:arf
+module print
+author "someone"
+require "io"
+---
+
+# a data section
+data rr small:U8 5u8 -3i8 7i32 2.5f32 1f64 9u64 12
+
+data rr huge:F64 1e999 -1e999
+
+data rr many:U64 1000000001 1000000002 1000000003 1000000004 1000000005
+        1000000006 1000000007 1000000008 1000000009 1000000010 1000000011
+
+type rr Greeter:Obj
+        rw count:Int 1u16 # how many
+        rw text:String "hi"
+
+func rr main
+        > argc:Int 2i32
+        < status:Int:mut 0u8
+        ---
+        let x:Int:mut
+        set x [+ argc 3u8 1.5f32 -1i64]
+        io.println "done" # trailing
+`
+
+/* TestPrintRoundTrip parses a module from a directory, prints it, and checks
+ * that parsing what was printed gives back a module that prints out the same
+ * way.
+ */
+func TestPrintRoundTrip (test *testing.T) {
+        first := printModule (
+                test, parseDirectory(test, printSource, false), "first")
+
+        for _, expected := range []string {
+                "5u8 -3i8 7i32 2.5f32 1.0f64 9u64 12\n",
+                "count:Int 1u16 # how many\n",
+                "argc:Int 2i32\n",
+                "status:Int:mut 0u8\n",
+                "[+ argc 3u8 1.5f32 -1i64]\n",
+                "huge:F64 1.7976931348623157e+308 -1.7976931348623157e+308\n",
+        } {
+                if !strings.Contains(first, expected) {
+                        test.Errorf (
+                                "printed module does not contain %q:\n%s",
+                                expected, first)
+                }
+        }
+
+        for _, line := range strings.Split(first, "\n") {
+                if utf8.RuneCountInString(line) > 80 {
+                        test.Errorf("line is too long: %q", line)
+                }
+        }
+
+        second := printModule (
+                test, parseDirectory(test, first, true), "second")
+        if first != second {
+                test.Errorf (
+                        "printing the module gives:\n%s\n" +
+                        "but printing it after parsing that gives:\n%s",
+                        first, second)
+        }
+}
+
+/* TestPrintBuiltLast checks that sections built from scratch are printed after
+ * sections that were parsed from a file.
+ */
+func TestPrintBuiltLast (test *testing.T) {
+        module := parseDirectory(test, printSource, false)
+
+        what, err := parser.NewType("Int")
+        if err != nil { test.Fatal(err) }
+        data, err := parser.NewData("rr", "aaa", what, 1)
+        if err != nil { test.Fatal(err) }
+        err = module.AddData(data)
+        if err != nil { test.Fatal(err) }
+
+        printed := printModule(test, module, "module")
+        if !strings.HasSuffix(printed, "\ndata rr aaa:Int 1\n") {
+                test.Errorf (
+                        "section built from scratch is not last:\n%s",
+                        printed)
+        }
+}
+
+/* parseDirectory writes source to a file in a new directory, and parses the
+ * module in it. If clean is true, the module must not have any mistakes in it.
+ */
+func parseDirectory (
+        test   *testing.T,
+        source string,
+        clean  bool,
+) (
+        module *parser.Module,
+) {
+        directory := test.TempDir()
+        err := os.WriteFile (
+                filepath.Join(directory, "print.arf"), []byte(source), 0644)
+        if err != nil { test.Fatal(err) }
+
+        collector := &diagnostic.Collector { }
+        module, err = parser.Parse (
+                filepath.Join(directory, "print"), false, collector)
+        if err != nil { test.Fatal(err) }
+
+        if clean {
+                for _, mistake := range collector.GetDiagnostics() {
+                        test.Error("unexpected diagnostic:", mistake.Message)
+                }
+        }
+        return
+}
+
+func printModule (
+        test   *testing.T,
+        module *parser.Module,
+        name   string,
+) (
+        printed string,
+) {
+        output := &bytes.Buffer { }
+        err := module.Print(output)
+        if err != nil { test.Fatalf("printing %s: %v", name, err) }
+        return output.String()
+}
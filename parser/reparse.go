@@ -0,0 +1,444 @@
+package parser
+
+import "bytes"
+import "errors"
+import "github.com/sashakoshka/arf/lexer"
+import "github.com/sashakoshka/arf/lineFile"
+import "github.com/sashakoshka/arf/diagnostic"
+
+var errNotInModule = errors.New("file is not part of this module")
+
+/* Reparse updates the module after the file at path has been changed to
+ * content, which is useful for editors that need to parse the same module over
+ * and over again as it is being typed. Only the top-level sections that
+ * overlap the part of the file that changed are lexed and parsed again, and
+ * everything else is kept. The module ends up exactly the same as it would be
+ * if it were parsed from scratch, and every diagnostic in it is reported to
+ * sink, just like Parse would. The path must be the path of a file that is
+ * already in the module.
+ *
+ * Sections that are kept are not copied, but moved to where they are in the new
+ * content. This means that any node taken from a kept section before Reparse
+ * was called has its position changed to refer to the new content. Sections
+ * that are parsed again are replaced with new ones, and nodes taken from the
+ * old ones are left as they were.
+ */
+func (module *Module) Reparse (
+        path    string,
+        content []byte,
+        sink    diagnostic.Sink,
+) (
+        err error,
+) {
+        index := -1
+        for sourceIndex, source := range module.sources {
+                if source.file.GetPath() == path { index = sourceIndex }
+        }
+        if index < 0 { return errNotInModule }
+
+        parser := &Parser {
+                module: module,
+                sink:   sink,
+        }
+        module.sources[index] = parser.reparseFile (
+                module.sources[index],
+                content)
+
+        // the sections of every file need to be added again, since the ones
+        // that are now duplicates may have changed
+        module.author    = ""
+        module.license   = ""
+        module.imports   = nil
+        module.functions = make(map[string] *Function)
+        module.typedefs  = make(map[string] *Typedef)
+        module.datas     = make(map[string] *Data)
+        for _, source := range module.sources {
+                parser.merge(source)
+        }
+
+        return nil
+}
+
+/* reparseFile updates a source file to match new content, parsing as little as
+ * possible. If the part that changed cannot be reparsed on its own, the whole
+ * file is reparsed instead. The new content gets a file of its own, and
+ * everything that is kept is moved into it, so the old file stays exactly as
+ * it was for the diagnostics that have already been reported in it.
+ */
+func (parser *Parser) reparseFile (
+        source  *sourceFile,
+        content []byte,
+) (
+        result *sourceFile,
+) {
+        file := source.file
+        if source.broken || len(source.chunks) == 0 {
+                return parser.reparseWholeFile(file, content)
+        }
+
+        // find which rows changed by skipping the ones at the start and the
+        // end that are still the same
+        edited, err := lineFile.FromBytes (
+                file.GetPath(), file.GetModule(), content)
+        if err != nil { return parser.reparseWholeFile(file, content) }
+        oldLength := file.GetLength()
+        newLength := edited.GetLength()
+
+        prefix := 0
+        for prefix < oldLength && prefix < newLength &&
+                file.GetLine(prefix) == edited.GetLine(prefix) { prefix ++ }
+        if prefix == oldLength && prefix == newLength { return source }
+
+        suffix := 0
+        for suffix < oldLength - prefix && suffix < newLength - prefix &&
+                file.GetLine(oldLength - suffix - 1) ==
+                edited.GetLine(newLength - suffix - 1) { suffix ++ }
+
+        // changes to the header can change anything, so those need the whole
+        // file to be reparsed
+        chunks := source.chunks
+        if prefix < chunks[0].startRow {
+                return parser.reparseWholeFile(file, content)
+        }
+
+        // the chunk before the change is reparsed as well, because the
+        // change might have made its last lines part of it. if the change
+        // touches the first line of code in a chunk, the chunk before that
+        // one ends there, so it is reparsed too.
+        first := source.findChunk(prefix - 1)
+        if first > 0 && chunks[first].first.Row >= prefix { first -- }
+        last  := source.findChunk(oldLength - suffix)
+
+        oldOffsets := make([]int, len(chunks))
+        for index, chunk := range chunks {
+                oldOffsets[index] = file.GetOffset(chunk.startRow, 0)
+        }
+
+        rowDelta := newLength - oldLength
+
+        // keep adding chunks until the changed rows can be lexed on their own,
+        // which is when the code in them starts at the start of a section and
+        // there are no comments left over at the end
+        var lines []*lexer.Line
+        var lexed *diagnostic.Collector
+        var startRow, endRow int
+        for {
+                startRow = chunks[first].startRow
+                endRow   = chunks[last].endRow + rowDelta
+                lexed    = &diagnostic.Collector { }
+
+                var dangling []*lexer.Comment
+                var err error
+                lines, dangling, err = lexer.TokenizeRows (
+                        edited, startRow, endRow, lexed)
+                if err != nil {
+                        return parser.reparseWholeFile(file, content)
+                }
+
+                if len(dangling) > 0 && last < len(chunks) - 1 {
+                        last ++
+                        continue
+                }
+                if len(lines) > 0 && lines[0].Indent != 0 && first > 0 {
+                        first --
+                        continue
+                }
+                break
+        }
+
+        // everything before the changed rows just needs to be moved into the
+        // new file, and everything after them needs to be moved down as well
+        source.header.shift(file, edited, 0, 0)
+        for _, chunk := range chunks[:first] {
+                chunk.shift(file, edited, 0, 0)
+        }
+        after := chunks[last + 1:]
+        if len(after) > 0 {
+                byteDelta :=
+                        edited.GetOffset(after[0].startRow + rowDelta, 0) -
+                        oldOffsets[last + 1]
+                for _, chunk := range after {
+                        chunk.shift(file, edited, rowDelta, byteDelta)
+                }
+        }
+
+        region, worked := parser.parseRegion (
+                source, edited, lines, after, endRow)
+        if !worked { return parser.reparseWholeFile(file, content) }
+        region.distribute(lexed.GetDiagnostics())
+
+        // whatever comes before the first section in the region belongs to
+        // the chunk before it
+        previous := &source.header
+        if first > 0 { previous = chunks[first - 1] }
+        previous.endRow = region.header.endRow
+        previous.lexed  = append(previous.lexed, region.header.lexed...)
+
+        result = &sourceFile {
+                file:     edited,
+                metadata: source.metadata,
+                header:   source.header,
+        }
+        if first == 0 { result.header = *previous }
+
+        result.chunks = append(result.chunks, chunks[:first]...)
+        result.chunks = append(result.chunks, region.chunks...)
+        result.chunks = append(result.chunks, after...)
+
+        scanned := &diagnostic.Collector { }
+        result.suppressions = lexer.ScanSuppressions(edited, scanned)
+        result.scanned = scanned.GetDiagnostics()
+        return
+}
+
+/* parseRegion parses the lines of a region of a file that has been lexed again.
+ * The first line of the chunks after the region is used to tell where the last
+ * section in the region ends.
+ */
+func (parser *Parser) parseRegion (
+        source *sourceFile,
+        file   *lineFile.LineFile,
+        lines  []*lexer.Line,
+        after  []*chunk,
+        endRow int,
+) (
+        region *sourceFile,
+        worked bool,
+) {
+        region = &sourceFile { file: file }
+        region.header.endRow = endRow
+        if len(lines) == 0 { return region, true }
+
+        regionParser := &Parser {
+                file:   file,
+                module: source.metadata,
+                source: region,
+                chunk:  &region.header,
+        }
+        regionParser.sink = diagnostic.SinkFunc(regionParser.record)
+
+        regionParser.lines = lines
+        if len(after) > 0 {
+                regionParser.lines = append(lines, after[0].first)
+        }
+        regionParser.line  = lines[0]
+        regionParser.token = lines[0].Tokens[0]
+
+        for regionParser.lineIndex < len(lines) {
+                regionParser.beginChunk()
+                err := regionParser.parseSection(parser.module.skim)
+                if err != nil { return nil, false }
+        }
+        if regionParser.lineIndex != len(lines) { return nil, false }
+
+        regionParser.chunk.endRow = endRow
+        return region, true
+}
+
+/* reparseWholeFile parses a file from scratch after its content has changed.
+ */
+func (parser *Parser) reparseWholeFile (
+        file    *lineFile.LineFile,
+        content []byte,
+) (
+        result *sourceFile,
+) {
+        moduleName := parser.module.name
+        return parser.parseFileAlone (func () (*lineFile.LineFile, error) {
+                edited, err := lineFile.FromBytes (
+                        file.GetPath(), file.GetModule(), content)
+                if err != nil { return edited, err }
+                
+                if ReadModuleName(bytes.NewReader(content)) != moduleName {
+                        return edited, errWrongModule
+                }
+                return edited, nil
+        }, parser.module.skim)
+}
+
+/* findChunk returns the index of the chunk that the specified row is in. Rows
+ * before the first chunk are counted as being in it, and rows after the last
+ * chunk are counted as being in that one.
+ */
+func (source *sourceFile) findChunk (row int) (index int) {
+        for index < len(source.chunks) - 1 &&
+                source.chunks[index + 1].startRow <= row { index ++ }
+        return
+}
+
+/* shift moves everything in a chunk from one version of a file into another,
+ * and down by the specified amount of rows and bytes.
+ */
+func (chunk *chunk) shift (
+        from  *lineFile.LineFile,
+        to    *lineFile.LineFile,
+        rows  int,
+        bytes int,
+) {
+        chunk.startRow += rows
+        chunk.endRow   += rows
+
+        first := chunk.first
+        if first != nil { shiftLine(first, rows, bytes) }
+
+        for index := range chunk.lexed {
+                chunk.lexed[index] = shiftDiagnostic (
+                        chunk.lexed[index], from, to, rows)
+        }
+        for index := range chunk.parsed {
+                chunk.parsed[index] = shiftDiagnostic (
+                        chunk.parsed[index], from, to, rows)
+        }
+
+        for _, parsed := range chunk.sections {
+                switch section := parsed.section.(type) {
+                case *Data:     section.shift(to, rows, bytes); break
+                case *Typedef:  section.shift(to, rows, bytes); break
+                case *Function: section.shift(to, rows, bytes); break
+                }
+        }
+}
+
+/* shiftLine moves a line that has already been lexed down by the specified
+ * amount of rows and bytes.
+ */
+func shiftLine (line *lexer.Line, rows int, bytes int) {
+        line.Row += rows
+        for _, token := range line.Tokens {
+                token.Row       += rows
+                token.EndRow    += rows
+                token.Offset    += bytes
+                token.EndOffset += bytes
+        }
+        for _, comment := range line.Comments {
+                comment.Row += rows
+        }
+        if line.Trailing != nil { line.Trailing.Row += rows }
+}
+
+/* shiftDiagnostic moves a diagnostic, and the labels of it that are in the
+ * same file, from one version of the file into another, and down by the
+ * specified amount of rows.
+ */
+func shiftDiagnostic (
+        mistake diagnostic.Diagnostic,
+        from    *lineFile.LineFile,
+        to      *lineFile.LineFile,
+        rows    int,
+) (
+        shifted diagnostic.Diagnostic,
+) {
+        shifted = mistake
+        if shifted.File == from { shifted.File = to }
+        if shifted.HasPosition() {
+                shifted.Row    += rows
+                shifted.EndRow += rows
+        }
+
+        // the labels might be shared with a copy of the diagnostic that has
+        // already been reported, so they have to be copied
+        shifted.Labels = make([]diagnostic.Label, len(mistake.Labels))
+        for index, label := range mistake.Labels {
+                if label.File == from {
+                        label.File    = to
+                        label.Row    += rows
+                        label.EndRow += rows
+                }
+                shifted.Labels[index] = label
+        }
+        return
+}
+
+/* shift moves a position into a new version of its file, and down by the
+ * specified amount of rows and bytes. Positions that were never filled in are
+ * left alone.
+ */
+func (where *Position) shift (file *lineFile.LineFile, rows int, bytes int) {
+        if where.file == nil { return }
+        where.file       = file
+        where.row       += rows
+        where.endRow    += rows
+        where.offset    += bytes
+        where.endOffset += bytes
+}
+
+func (data *Data) shift (file *lineFile.LineFile, rows int, bytes int) {
+        data.where.shift(file, rows, bytes)
+        data.what.shift(file, rows, bytes)
+}
+
+func (typedef *Typedef) shift (file *lineFile.LineFile, rows int, bytes int) {
+        typedef.where.shift(file, rows, bytes)
+        typedef.inherits.shift(file, rows, bytes)
+        for _, member := range typedef.members {
+                member.shift(file, rows, bytes)
+        }
+}
+
+func (function *Function) shift (
+        file  *lineFile.LineFile,
+        rows  int,
+        bytes int,
+) {
+        function.where.shift(file, rows, bytes)
+        if function.root != nil { function.root.shift(file, rows, bytes) }
+}
+
+func (what *Type) shift (file *lineFile.LineFile, rows int, bytes int) {
+        what.where.shift(file, rows, bytes)
+        what.name.where.shift(file, rows, bytes)
+        if what.points != nil { what.points.shift(file, rows, bytes) }
+}
+
+func (block *Block) shift (file *lineFile.LineFile, rows int, bytes int) {
+        block.where.shift(file, rows, bytes)
+        for _, variable := range block.variables {
+                variable.where.shift(file, rows, bytes)
+                variable.what.shift(file, rows, bytes)
+        }
+        for _, item := range block.items {
+                item.where.shift(file, rows, bytes)
+                if item.block != nil {
+                        item.block.shift(file, rows, bytes)
+                }
+                if item.statement != nil {
+                        item.statement.shift(file, rows, bytes)
+                }
+        }
+}
+
+func (statement *Statement) shift (
+        file  *lineFile.LineFile,
+        rows  int,
+        bytes int,
+) {
+        statement.where.shift(file, rows, bytes)
+        statement.command.where.shift(file, rows, bytes)
+        for index := range statement.arguments {
+                statement.arguments[index].shift(file, rows, bytes)
+        }
+        for _, identifier := range statement.returnsTo {
+                identifier.where.shift(file, rows, bytes)
+        }
+}
+
+func (argument *Argument) shift (
+        file  *lineFile.LineFile,
+        rows  int,
+        bytes int,
+) {
+        argument.where.shift(file, rows, bytes)
+        if argument.statementValue != nil {
+                argument.statementValue.shift(file, rows, bytes)
+        }
+        if argument.identifierValue != nil {
+                argument.identifierValue.where.shift(file, rows, bytes)
+        }
+        if argument.dereferenceValue != nil {
+                dereference := argument.dereferenceValue
+                dereference.where.shift(file, rows, bytes)
+                if dereference.dereferences != nil {
+                        dereference.dereferences.shift(file, rows, bytes)
+                }
+        }
+}
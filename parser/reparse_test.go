@@ -0,0 +1,291 @@
+package parser_test
+
+import "fmt"
+import "bytes"
+import "testing"
+import "strings"
+import "github.com/sashakoshka/arf/parser"
+import "github.com/sashakoshka/arf/diagnostic"
+
+const reparseFirst =
+`:arf
+module reparse
+author "someone"
+---
+
+# the first section
+data rr first:Int 1
+
+data rr second:String """
+        # arf:allow nope
+        text
+
+type rr Greeter:Obj
+        # a member
+        rw text:String "hi"
+
+func rr main
+        > argc:Int
+        < status:Int:mut 0
+        ---
+        let greeting:String
+        [io.println greeting] # trailing
+        set status argc
+
+func rr helper
+        ---
+        io.println "helper" ->
+`
+
+const reparseSecond =
+`:arf
+module reparse
+---
+
+data rr other:Int 5
+`
+
+func TestReparse (test *testing.T) {
+        cases := []struct {
+                name    string
+                file    string
+                content string
+        } {
+                { "nothing", "first.arf", reparseFirst },
+                { "value", "first.arf", strings.Replace (
+                        reparseFirst, "first:Int 1", "first:Int 2", 1) },
+                { "insert section", "first.arf", strings.Replace (
+                        reparseFirst, "type rr Greeter",
+                        "data rr inserted:Int 3\n\ntype rr Greeter", 1) },
+                { "insert lines", "first.arf", strings.Replace (
+                        reparseFirst, "        set status argc",
+                        "        io.println \"a\"\n" +
+                        "        io.println \"b\"\n" +
+                        "        set status argc", 1) },
+                { "delete section", "first.arf", strings.Replace (
+                        reparseFirst,
+                        "type rr Greeter:Obj\n" +
+                        "        # a member\n" +
+                        "        rw text:String \"hi\"\n\n", "", 1) },
+                { "break statement", "first.arf", strings.Replace (
+                        reparseFirst, "[io.println greeting]",
+                        "[io.println greeting", 1) },
+                { "add comment", "first.arf", strings.Replace (
+                        reparseFirst, "func rr helper",
+                        "# a new comment\nfunc rr helper", 1) },
+                { "indent section", "first.arf", strings.Replace (
+                        reparseFirst, "func rr helper",
+                        "        func rr helper", 1) },
+                { "edit string", "first.arf", strings.Replace (
+                        reparseFirst, "        text\n",
+                        "        text\n        # arf:allow another\n", 1) },
+                { "edit header", "first.arf", strings.Replace (
+                        reparseFirst, "author \"someone\"",
+                        "author \"someone else\"", 1) },
+                { "wrong module", "first.arf", strings.Replace (
+                        reparseFirst, "module reparse",
+                        "module other", 1) },
+                { "duplicate", "second.arf", strings.Replace (
+                        reparseSecond, "other:Int 5",
+                        "first:Int 5", 1) },
+                { "immutable output", "first.arf", strings.Replace (
+                        reparseFirst, "status:Int:mut 0",
+                        "status:Int 0", 1) },
+                { "last line", "first.arf", reparseFirst +
+                        "        io.println \"more\"\n" },
+        }
+
+        for _, testCase := range cases {
+                module, _ := parseReparseModule (
+                        test, reparseFirst, reparseSecond)
+
+                reparsed := &bytes.Buffer { }
+                err := module.Reparse (
+                        testCase.file, []byte(testCase.content),
+                        &diagnostic.JSONWriter { Output: reparsed })
+                if err != nil {
+                        test.Errorf("%s: %v", testCase.name, err)
+                        continue
+                }
+
+                first, second := reparseFirst, reparseSecond
+                if testCase.file == "first.arf" {
+                        first = testCase.content
+                } else {
+                        second = testCase.content
+                }
+                expectedModule, parsed := parseReparseModule (
+                        test, first, second)
+
+                got      := describeModule(module) + reparsed.String()
+                expected := describeModule(expectedModule) + parsed
+                if got != expected {
+                        test.Errorf (
+                                "%s: reparsing gives:\n%s\n" +
+                                "but parsing gives:\n%s",
+                                testCase.name, got, expected)
+                }
+        }
+}
+
+/* TestReparseSeveral checks that a module stays right when it is reparsed over
+ * and over again, like it would be as it is being typed.
+ */
+func TestReparseSeveral (test *testing.T) {
+        module, _ := parseReparseModule(test, reparseFirst, reparseSecond)
+
+        content := reparseFirst
+        for _, edit := range [][2]string {
+                { "first:Int 1",         "first:Int 12"                    },
+                { "        set status",  "        io.println \"x\"\n" +
+                                         "        set status"               },
+                { "data rr second",      "data rr added:Int 1\n\n" +
+                                         "data rr second"                   },
+                { "io.println \"x\"\n", ""                                },
+                { "first:Int 12",        "first:Int 1"                     },
+        } {
+                content = strings.Replace(content, edit[0], edit[1], 1)
+
+                reparsed := &bytes.Buffer { }
+                err := module.Reparse (
+                        "first.arf", []byte(content),
+                        &diagnostic.JSONWriter { Output: reparsed })
+                if err != nil { test.Fatal(err) }
+
+                expectedModule, parsed := parseReparseModule (
+                        test, content, reparseSecond)
+                got      := describeModule(module) + reparsed.String()
+                expected := describeModule(expectedModule) + parsed
+                if got != expected {
+                        test.Fatalf (
+                                "after replacing %q, reparsing gives:\n%s\n" +
+                                "but parsing gives:\n%s",
+                                edit[0], got, expected)
+                }
+        }
+}
+
+/* TestReparseKeepsReported checks that diagnostics reported before a file is
+ * reparsed still show the content that they were reported in.
+ */
+func TestReparseKeepsReported (test *testing.T) {
+        collector := &diagnostic.Collector { }
+        module, err := parser.ParseSources ("reparse", map[string] []byte {
+                "first.arf":  []byte(reparseFirst),
+                "second.arf": []byte(reparseSecond),
+        }, false, collector)
+        if err != nil { test.Fatal(err) }
+
+        render := func () (output string) {
+                buffer   := &bytes.Buffer { }
+                renderer := &diagnostic.Renderer { Output: buffer }
+                for _, mistake := range collector.GetDiagnostics() {
+                        renderer.Report(mistake)
+                }
+                return buffer.String()
+        }
+
+        before := render()
+        if before == "" { test.Fatal("nothing was reported") }
+        err = module.Reparse ("first.arf", []byte (strings.Replace (
+                reparseFirst, "        # arf:allow nope\n",
+                "        changed\n        # arf:allow nope\n", 1),
+        ), &diagnostic.Collector { })
+        if err != nil { test.Fatal(err) }
+
+        after := render()
+        if before != after {
+                test.Errorf (
+                        "diagnostics changed after reparsing:\n%s\n" +
+                        "they were:\n%s",
+                        after, before)
+        }
+}
+
+func parseReparseModule (
+        test   *testing.T,
+        first  string,
+        second string,
+) (
+        module      *parser.Module,
+        diagnostics string,
+) {
+        output := &bytes.Buffer { }
+        module, err := parser.ParseSources ("reparse", map[string] []byte {
+                "first.arf":  []byte(first),
+                "second.arf": []byte(second),
+        }, false, &diagnostic.JSONWriter { Output: output })
+        if err != nil { test.Fatal(err) }
+        return module, output.String()
+}
+
+/* describeModule prints out a module, along with where every node in it is and
+ * the line of the file that it starts on.
+ */
+func describeModule (module *parser.Module) (description string) {
+        output := &bytes.Buffer { }
+        module.Print(output)
+
+        parser.Inspect(module, func (node parser.Node) bool {
+                if node == nil { return true }
+
+                where := node.GetPosition()
+                file  := where.GetFile()
+                if file == nil { return true }
+
+                fmt.Fprintf (
+                        output, "%T %s %d:%d-%d:%d %d-%d %q\n",
+                        node, file.GetPath(),
+                        where.GetRow(), where.GetColumn(),
+                        where.GetEndRow(), where.GetEndColumn(),
+                        where.GetOffset(), where.GetEndOffset(),
+                        file.GetLine(where.GetRow()))
+                return true
+        })
+        return output.String()
+}
+
+/* TestReparseMovesKept checks that sections kept by Reparse are moved to where
+ * they are in the new content, and that sections which are parsed again leave
+ * the old ones as they were.
+ */
+func TestReparseMovesKept (test *testing.T) {
+        module, _ := parseReparseModule(test, reparseFirst, reparseSecond)
+        helper, _ := module.GetFunction("helper")
+        first,  _ := module.GetData("first")
+        helperRow := helper.GetPosition().GetRow()
+        firstRow  := first.GetPosition().GetRow()
+
+        err := module.Reparse ("first.arf", []byte (strings.Replace (
+                reparseFirst, "data rr first:Int 1",
+                "data rr first:Int\n        2", 1),
+        ), &diagnostic.Collector { })
+        if err != nil { test.Fatal(err) }
+
+        kept, _ := module.GetFunction("helper")
+        if kept != helper { test.Fatal("helper was not kept") }
+        where := helper.GetPosition()
+        if where.GetRow() != helperRow + 1 {
+                test.Errorf (
+                        "helper is on row %d, expected %d",
+                        where.GetRow(), helperRow + 1)
+        }
+        line := where.GetFile().GetLine(where.GetRow())
+        if line != "func rr helper" {
+                test.Errorf("helper points to %q", line)
+        }
+
+        replaced, _ := module.GetData("first")
+        if replaced == first { test.Fatal("first was not parsed again") }
+        values := first.GetDefaultValues()
+        if len(values) != 1 || values[0] != uint64(1) {
+                test.Errorf("old first has changed to %v", values)
+        }
+        where = first.GetPosition()
+        line  = where.GetFile().GetLine(where.GetRow())
+        if where.GetRow() != firstRow || line != "data rr first:Int 1" {
+                test.Errorf (
+                        "old first has moved to row %d, %q",
+                        where.GetRow(), line)
+        }
+}
@@ -0,0 +1,130 @@
+package parser_test
+
+import "strings"
+import "testing"
+import "github.com/sashakoshka/arf/parser"
+import "github.com/sashakoshka/arf/diagnostic"
+
+func TestParseSources (test *testing.T) {
+        sources := map[string] []byte {
+                "b.arf": []byte (
+`:arf
+module memory
+---
+
+data rr second:Int 2
+`),
+                "a.arf": []byte (
+`:arf
+module memory
+author "someone"
+---
+
+data rr first:Int 1
+`),
+        }
+
+        collector := &diagnostic.Collector { }
+        module, err := parser.ParseSources("memory", sources, false, collector)
+        if err != nil { test.Fatal(err) }
+        for _, mistake := range collector.GetDiagnostics() {
+                test.Error("unexpected diagnostic:", mistake.Message)
+        }
+
+        name, author, _, _ := module.GetMetadata()
+        if name   != "memory"  { test.Error("wrong module name", name) }
+        if author != "someone" { test.Error("wrong author", author)    }
+
+        for _, expected := range []struct {
+                dataName string
+                fileName string
+                row      int
+        } {
+                { "first",  "a.arf", 5 },
+                { "second", "b.arf", 4 },
+        } {
+                dataName := expected.dataName
+                fileName := expected.fileName
+                data, exists := module.GetData(dataName)
+                if !exists {
+                        test.Error("missing data", dataName)
+                        continue
+                }
+
+                where := data.GetPosition()
+                if where.GetFile().GetPath() != fileName {
+                        test.Error (
+                                dataName, "is in", where.GetFile().GetPath(),
+                                "instead of", fileName)
+                }
+                if where.GetRow() != expected.row {
+                        test.Error(dataName, "is on row", where.GetRow())
+                }
+        }
+}
+
+func TestParseSourcesWrongModule (test *testing.T) {
+        sources := map[string] []byte {
+                "stray.arf": []byte (
+`:arf
+module other
+---
+
+data rr stray:Int 1
+`),
+        }
+
+        collector := &diagnostic.Collector { }
+        parser.ParseSources("memory", sources, false, collector)
+
+        diagnostics := collector.GetDiagnostics()
+        if len(diagnostics) != 1 {
+                test.Fatal("expected 1 diagnostic, got", len(diagnostics))
+        }
+        if diagnostics[0].Code != diagnostic.CodeWrongModule {
+                test.Error("wrong code", diagnostics[0].Code)
+        }
+        if diagnostics[0].GetPath() != "stray.arf" {
+                test.Error("wrong file", diagnostics[0].GetPath())
+        }
+}
+
+func TestParseReader (test *testing.T) {
+        reader := strings.NewReader (
+`:arf
+module reader
+---
+
+func rr main
+        ---
+        io.println "hello"
+`)
+
+        collector := &diagnostic.Collector { }
+        module, err := parser.ParseReader("buffer", reader, false, collector)
+        if err != nil { test.Fatal(err) }
+        for _, mistake := range collector.GetDiagnostics() {
+                test.Error("unexpected diagnostic:", mistake.Message)
+        }
+
+        if module.GetName() != "reader" {
+                test.Error("wrong module name", module.GetName())
+        }
+
+        function, exists := module.GetFunction("main")
+        if !exists { test.Fatal("missing function main") }
+        where := function.GetPosition()
+        if where.GetFile().GetPath() != "buffer" {
+                test.Error("wrong file", where.GetFile().GetPath())
+        }
+}
+
+func TestParseReaderNotArf (test *testing.T) {
+        collector := &diagnostic.Collector { }
+        _, err := parser.ParseReader (
+                "buffer", strings.NewReader("hello\n"), false, collector)
+        if err == nil { test.Error("file without :arf was accepted") }
+        if len(collector.GetDiagnostics()) != 1 {
+                test.Error("error was not reported")
+        }
+}
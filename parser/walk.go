@@ -0,0 +1,216 @@
+package parser
+
+import "sort"
+
+/* Visitor is used by Walk to visit each node in the AST. Visit is called for a
+ * node before any of its children. If it returns a visitor, that visitor is
+ * used to visit the children, and afterwards it is called once more with a nil
+ * node. If it returns nil, the children are skipped.
+ */
+type Visitor interface {
+        Visit (node Node) (next Visitor)
+}
+
+/* Walk goes through the AST depth first, starting at node, and calls visitor
+ * for each node along the way. The children of a node are visited in the order
+ * that they appear in the source code:
+ *
+ *   - the sections of a module, sorted by file and then position
+ *   - the type and members of a type definition
+ *   - the type of a data section or a variable
+ *   - the root block of a function
+ *   - the variables of a block, then its statements and blocks
+ *   - the command, arguments, and return identifiers of a statement
+ *   - the statement, identifier, or dereference of an argument
+ *   - the argument of a dereference
+ *   - the name of a type, or the type that it points to
+ *
+ * The receiver and arguments of a function are variables in its root block.
+ * Children that are nil are skipped, so that a nil pointer never reaches the
+ * visitor wrapped in a Node that is not nil.
+ */
+func Walk (visitor Visitor, node Node) {
+        visitor = visitor.Visit(node)
+        if visitor == nil { return }
+
+        switch node := node.(type) {
+        case *Module:
+                for _, section := range node.getSectionsInOrder() {
+                        Walk(visitor, section)
+                }
+                break
+        case *Typedef:
+                Walk(visitor, &node.inherits)
+                for _, member := range node.members {
+                        if member != nil { Walk(visitor, member) }
+                }
+                break
+        case *Data:
+                Walk(visitor, &node.what)
+                break
+        case *Variable:
+                Walk(visitor, &node.what)
+                break
+        case *Function:
+                if node.root != nil { Walk(visitor, node.root) }
+                break
+        case *Block:
+                for _, variable := range node.getVariablesInOrder() {
+                        Walk(visitor, variable)
+                }
+                for _, item := range node.items {
+                        if item.block != nil {
+                                Walk(visitor, item.block)
+                        } else if item.statement != nil {
+                                Walk(visitor, item.statement)
+                        }
+                }
+                break
+        case *Statement:
+                if !node.external { Walk(visitor, &node.command) }
+                for index := range node.arguments {
+                        Walk(visitor, &node.arguments[index])
+                }
+                for _, identifier := range node.returnsTo {
+                        if identifier != nil { Walk(visitor, identifier) }
+                }
+                break
+        case *Argument:
+                switch node.kind {
+                case ArgumentKindStatement:
+                        if node.statementValue != nil {
+                                Walk(visitor, node.statementValue)
+                        }
+                        break
+                case ArgumentKindIdentifier:
+                        if node.identifierValue != nil {
+                                Walk(visitor, node.identifierValue)
+                        }
+                        break
+                case ArgumentKindDereference:
+                        if node.dereferenceValue != nil {
+                                Walk(visitor, node.dereferenceValue)
+                        }
+                        break
+                }
+                break
+        case *Dereference:
+                if node.dereferences != nil {
+                        Walk(visitor, node.dereferences)
+                }
+                break
+        case *Type:
+                if node.points != nil {
+                        Walk(visitor, node.points)
+                } else {
+                        Walk(visitor, &node.name)
+                }
+                break
+        }
+
+        visitor.Visit(nil)
+}
+
+/* inspector turns a function into a Visitor.
+ */
+type inspector func (node Node) (keepGoing bool)
+
+func (inspect inspector) Visit (node Node) (next Visitor) {
+        if inspect(node) { return inspect }
+        return nil
+}
+
+/* Inspect goes through the AST the same way Walk does, calling inspect for each
+ * node. If inspect returns false, the children of the node are skipped. After
+ * the children of a node are visited, inspect is called with nil.
+ */
+func Inspect (node Node, inspect func (node Node) (keepGoing bool)) {
+        Walk(inspector(inspect), node)
+}
+
+/* getSectionsInOrder returns every section in the module, sorted by the file
+ * they are in and where they are in it.
+ */
+func (module *Module) getSectionsInOrder () (sections []Node) {
+        for _, section := range module.typedefs {
+                sections = append(sections, section)
+        }
+        for _, section := range module.datas {
+                sections = append(sections, section)
+        }
+        for _, section := range module.functions {
+                sections = append(sections, section)
+        }
+
+        sortNodes(sections)
+        return
+}
+
+/* getVariablesInOrder returns every variable declared in a block, sorted by
+ * where they are declared.
+ */
+func (block *Block) getVariablesInOrder () (variables []Node) {
+        for _, variable := range block.variables {
+                variables = append(variables, variable)
+        }
+
+        sortNodes(variables)
+        return
+}
+
+/* namedNode is a node that has a name, such as a section or a variable.
+ */
+type namedNode interface {
+        Node
+        GetName () (name string)
+}
+
+/* sortNodes sorts named nodes by the file they are in and where they are in it.
+ * Nodes that were not parsed from a file go after all of the ones that were.
+ * Nodes that are in the same place, such as ones that were not parsed from a
+ * file, are sorted by kind and then name.
+ */
+func sortNodes (nodes []Node) {
+        sort.Slice(nodes, func (left, right int) bool {
+                leftWhere  := nodes[left].GetPosition()
+                rightWhere := nodes[right].GetPosition()
+
+                leftPath  := leftWhere.getPath()
+                rightPath := rightWhere.getPath()
+                if leftPath != rightPath {
+                        if leftPath  == "" { return false }
+                        if rightPath == "" { return true  }
+                        return leftPath < rightPath
+                }
+                
+                if leftWhere.offset != rightWhere.offset {
+                        return leftWhere.offset < rightWhere.offset
+                }
+
+                leftRank  := getKindRank(nodes[left])
+                rightRank := getKindRank(nodes[right])
+                if leftRank != rightRank { return leftRank < rightRank }
+                
+                return nodes[left].(namedNode).GetName() <
+                        nodes[right].(namedNode).GetName()
+        })
+}
+
+/* getKindRank returns where a kind of node goes when it is sorted along with
+ * other kinds: types come first, then data, then functions.
+ */
+func getKindRank (node Node) (rank int) {
+        switch node.(type) {
+        case *Data:     return 1
+        case *Function: return 2
+        }
+        return 0
+}
+
+/* getPath returns the path of the file that the position is in, or an empty
+ * string if it is not in one.
+ */
+func (where Position) getPath () (path string) {
+        if where.file == nil { return "" }
+        return where.file.GetPath()
+}
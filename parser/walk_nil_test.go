@@ -0,0 +1,41 @@
+package parser
+
+import "testing"
+
+/* TestInspectSkipsNil walks a module where the children of some nodes are left
+ * as nil, and checks that none of them are passed on to the visitor.
+ */
+func TestInspectSkipsNil (test *testing.T) {
+        module, err := NewModule("walk")
+        if err != nil { test.Fatal(err) }
+
+        statement := &Statement {
+                command: Identifier { trail: []string { "call" } },
+                arguments: []Argument {
+                        { kind: ArgumentKindStatement   },
+                        { kind: ArgumentKindIdentifier  },
+                        { kind: ArgumentKindDereference },
+                },
+                returnsTo: []*Identifier { nil },
+        }
+        function := &Function { name: "main", root: NewBlock() }
+        function.root.items = []BlockOrStatement { { statement: statement } }
+        module.functions["main"] = function
+
+        inherits, err := NewType("Obj")
+        if err != nil { test.Fatal(err) }
+        module.typedefs["Thing"] = &Typedef {
+                name:     "Thing",
+                inherits: inherits,
+                members:  []*Data { nil },
+        }
+
+        visited := 0
+        Inspect(module, func (node Node) bool {
+                if node == nil { return true }
+                visited ++
+                node.GetPosition()
+                return true
+        })
+        if visited == 0 { test.Error("nothing was visited") }
+}
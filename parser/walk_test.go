@@ -0,0 +1,81 @@
+package parser_test
+
+import "testing"
+import "github.com/sashakoshka/arf/parser"
+import "github.com/sashakoshka/arf/diagnostic"
+
+/* TestInspect walks through the main module in the tests directory, which is
+ * made up of tests/full.arf, and checks that the position and comments of each
+ * node can be read straight off of the node.
+ */
+func TestInspect (test *testing.T) {
+        collector := &diagnostic.Collector { }
+        module, err := parser.Parse("../tests/main", false, collector)
+        if err != nil { test.Fatal(err) }
+
+        leading := map[string] string { }
+        rows    := map[string] int    { }
+        parser.Inspect(module, func (node parser.Node) bool {
+                if node == nil { return true }
+
+                if node.GetPosition().GetFile() != nil &&
+                        node.GetPosition().GetRow() < 0 {
+                        test.Errorf("%T has a negative row", node)
+                }
+
+                switch node := node.(type) {
+                case *parser.Data:
+                        rows[node.GetName()] = node.GetPosition().GetRow()
+                        leading[node.GetName()] =
+                                firstComment(node.GetComments().GetLeading())
+                        break
+
+                case *parser.Typedef:
+                        rows[node.GetName()] = node.GetPosition().GetRow()
+                        leading[node.GetName()] =
+                                firstComment(node.GetComments().GetLeading())
+                        break
+
+                case *parser.Function:
+                        rows[node.GetName()] = node.GetPosition().GetRow()
+                        leading[node.GetName()] =
+                                firstComment(node.GetComments().GetLeading())
+                        break
+                }
+                return true
+        })
+
+        for _, expected := range []struct {
+                name    string
+                row     int
+                comment string
+        } {
+                { "helloText", 8,  "# this is a global variable"       },
+                { "Greeter",   11, "# this is a struct definition"     },
+                { "main",      20, "# this is a function"              },
+                { "greet",     30, "# this is a member function"       },
+                { "setText",   36, "# this is mutator member function" },
+        } {
+                row, found := rows[expected.name]
+                if !found {
+                        test.Errorf("%s was not visited", expected.name)
+                        continue
+                }
+                if row != expected.row {
+                        test.Errorf (
+                                "%s is on row %d, expected %d",
+                                expected.name, row, expected.row)
+                }
+                if leading[expected.name] != expected.comment {
+                        test.Errorf (
+                                "%s has comment %q, expected %q",
+                                expected.name, leading[expected.name],
+                                expected.comment)
+                }
+        }
+}
+
+func firstComment (comments []string) (comment string) {
+        if len(comments) == 0 { return "" }
+        return comments[0]
+}
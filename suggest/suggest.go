@@ -0,0 +1,87 @@
+package suggest
+
+/* Closest returns the candidate that is most similar to word, if any of them
+ * are similar enough to be worth suggesting to the user. If several are
+ * equally similar, the one that comes first is returned.
+ */
+func Closest (word string, candidates ...string) (closest string, found bool) {
+        best := threshold(word) + 1
+        for _, candidate := range candidates {
+                distance := Distance(word, candidate)
+                if distance < best {
+                        best    = distance
+                        closest = candidate
+                        found   = true
+                }
+        }
+        return
+}
+
+/* DidYouMean returns a note asking if the user meant the candidate closest to
+ * word. If none of the candidates are close enough, it returns nothing. The
+ * result can be passed straight to Diagnostic.WithNotes.
+ */
+func DidYouMean (word string, candidates ...string) (notes []string) {
+        closest, found := Closest(word, candidates...)
+        if !found || closest == word { return nil }
+        return []string { "did you mean \"" + closest + "\"?" }
+}
+
+/* Distance returns the amount of edits it takes to turn left into right, where
+ * an edit is inserting, deleting, or replacing a single rune, or swapping two
+ * adjacent runes.
+ */
+func Distance (left, right string) (distance int) {
+        leftRunes  := []rune(left)
+        rightRunes := []rune(right)
+
+        // table[i][j] is the distance between the first i runes of left and
+        // the first j runes of right.
+        table := make([][]int, len(leftRunes) + 1)
+        for i := range table {
+                table[i] = make([]int, len(rightRunes) + 1)
+                table[i][0] = i
+        }
+        for j := range table[0] {
+                table[0][j] = j
+        }
+
+        for i := 1; i <= len(leftRunes); i ++ {
+                for j := 1; j <= len(rightRunes); j ++ {
+                        cost := 1
+                        if leftRunes[i - 1] == rightRunes[j - 1] { cost = 0 }
+
+                        table[i][j] = minimum (
+                                table[i - 1][j] + 1,
+                                table[i][j - 1] + 1,
+                                table[i - 1][j - 1] + cost)
+
+                        swapped :=
+                                i > 1 && j > 1 &&
+                                leftRunes[i - 1] == rightRunes[j - 2] &&
+                                leftRunes[i - 2] == rightRunes[j - 1]
+                        if swapped {
+                                table[i][j] = minimum (
+                                        table[i][j],
+                                        table[i - 2][j - 2] + 1)
+                        }
+                }
+        }
+
+        return table[len(leftRunes)][len(rightRunes)]
+}
+
+/* threshold returns the largest distance that a candidate can be from word
+ * while still being suggested. Longer words are allowed more mistakes.
+ */
+func threshold (word string) (distance int) {
+        return (len([]rune(word)) + 2) / 3
+}
+
+func minimum (values ...int) (least int) {
+        least = values[0]
+        for _, value := range values[1:] {
+                if value < least { least = value }
+        }
+        return
+}
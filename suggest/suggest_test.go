@@ -0,0 +1,112 @@
+package suggest
+
+import "testing"
+
+func TestDistance (test *testing.T) {
+        cases := []struct {
+                left     string
+                right    string
+                distance int
+        } {
+                { "",        "",        0 },
+                { "func",    "func",    0 },
+                { "",        "func",    4 },
+                { "func",    "",        4 },
+                { "requre",  "require", 1 },
+                { "fnuc",    "func",    1 },
+                { "mutt",    "mut",     1 },
+                { "data",    "date",    1 },
+                { "kitten",  "sitting", 3 },
+                { "ab",      "ba",      1 },
+                { "abc",     "ca",      3 },
+                { "héllo",   "hello",   1 },
+                { "日本",    "本日",    1 },
+        }
+
+        for _, testCase := range cases {
+                distance := Distance(testCase.left, testCase.right)
+                if distance != testCase.distance {
+                        test.Errorf (
+                                "distance from %q to %q is %d, expected %d",
+                                testCase.left, testCase.right, distance,
+                                testCase.distance)
+                }
+        }
+}
+
+func TestThreshold (test *testing.T) {
+        cases := []struct {
+                word      string
+                threshold int
+        } {
+                { "",        0 },
+                { "a",       1 },
+                { "mut",     1 },
+                { "func",    2 },
+                { "module",  2 },
+                { "require", 3 },
+                { "日本語",  1 },
+        }
+
+        for _, testCase := range cases {
+                if threshold(testCase.word) != testCase.threshold {
+                        test.Errorf (
+                                "threshold of %q is %d, expected %d",
+                                testCase.word, threshold(testCase.word),
+                                testCase.threshold)
+                }
+        }
+}
+
+func TestClosest (test *testing.T) {
+        directives := []string { "module", "author", "license", "require" }
+        keywords   := []string { "data", "type", "func", "face", "enum" }
+
+        cases := []struct {
+                word       string
+                candidates []string
+                closest    string
+                found      bool
+        } {
+                { "requre",  directives,              "require", true  },
+                { "fnuc",    keywords,                "func",    true  },
+                { "mutt",    []string { "mut" },      "mut",     true  },
+                { "licence", directives,              "license", true  },
+                { "dat",     keywords,                "data",    true  },
+
+                // the first of several equally close candidates wins
+                { "fac",     []string { "fab", "face" }, "fab",  true  },
+
+                // too far from every candidate
+                { "banana",  directives,              "",        false },
+                { "xyz",     keywords,                "",        false },
+                { "mu",      []string { "module" },   "",        false },
+                { "func",    nil,                     "",        false },
+        }
+
+        for _, testCase := range cases {
+                closest, found := Closest (
+                        testCase.word, testCase.candidates...)
+                if closest != testCase.closest || found != testCase.found {
+                        test.Errorf (
+                                "closest to %q is %q, %v, expected %q, %v",
+                                testCase.word, closest, found,
+                                testCase.closest, testCase.found)
+                }
+        }
+}
+
+func TestDidYouMean (test *testing.T) {
+        notes := DidYouMean("requre", "module", "require")
+        if len(notes) != 1 || notes[0] != "did you mean \"require\"?" {
+                test.Errorf("wrong notes %q", notes)
+        }
+
+        if notes := DidYouMean("banana", "module", "require"); notes != nil {
+                test.Errorf("%q was suggested for a word too far away", notes)
+        }
+
+        if notes := DidYouMean("module", "module", "require"); notes != nil {
+                test.Errorf("%q was suggested for a correct word", notes)
+        }
+}
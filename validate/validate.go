@@ -1,29 +1,176 @@
 package validate
 
+import "sort"
+import "sync"
+import "unicode"
+
 /* ValidateName returns whether a module/variable/function/type name is valid.
- * The name must start with an alphabetical character, and contain only alpha-
- * numeric characters. Symbols such as dashes and underscores are not
- * considered valid.
+ * The name must start with a letter, and contain only letters, digits, and
+ * combining marks. Letters and digits from any script are allowed, following
+ * the identifier syntax from UAX #31. Symbols such as dashes and underscores
+ * are not considered valid.
  */
 func ValidateName (name string) (valid bool) {
         runes := []rune(name)
         // symbols must be at least two letters, and start with an alphabetic
         // character.
         if len(runes) < 2 { return false }
-        lower := runes[0] < 'a' || runes[0] > 'z'
-        upper := runes[0] < 'Z' || runes[0] > 'Z'
-        if !lower && !upper { return false }
+        if !IsNameStart(runes[0]) { return false }
         
         for _, ch := range runes {
-                // keep going if the character is valid
-                if ch >= 'a' && ch <= 'z' { continue }
-                if ch >= 'A' && ch <= 'Z' { continue }
-                if ch >= '0' && ch <= '9' { continue }
+                if !IsNameContinue(ch) { return false }
+        }
+
+        return true
+}
+
+/* IsNameStart returns whether a name can start with the specified rune. This
+ * is true for letters in any script, including letter-like numbers such as
+ * roman numerals.
+ */
+func IsNameStart (ch rune) (valid bool) {
+        return unicode.IsLetter(ch) || unicode.Is(unicode.Nl, ch)
+}
+
+/* IsNameContinue returns whether the specified rune can come after the first
+ * rune of a name. On top of what can start a name, this allows decimal digits
+ * and combining marks in any script. Unlike UAX #31, connector punctuation such
+ * as underscores is not allowed.
+ */
+func IsNameContinue (ch rune) (valid bool) {
+        return IsNameStart(ch) ||
+                unicode.Is(unicode.Nd, ch) ||
+                unicode.Is(unicode.Mn, ch) ||
+                unicode.Is(unicode.Mc, ch)
+}
+
+/* scriptMixes lists the combinations of scripts that are commonly written
+ * together, and so can be mixed within a name. These come from the "highly
+ * restrictive" level of UTS #39.
+ */
+var scriptMixes = [][]string {
+        { "Latin", "Han", "Hiragana", "Katakana" },
+        { "Latin", "Han", "Bopomofo" },
+        { "Latin", "Han", "Hangul" },
+}
+
+/* GetMixedScripts returns the scripts that a name is written in, if it mixes
+ * scripts that are not normally written together. Names like this are often
+ * made of characters that look alike, such as a Latin "a" and a Cyrillic "а",
+ * which makes two different names look like the same one. If the name is not
+ * suspicious, it returns nil. Characters that are shared between scripts, such
+ * as digits, are ignored.
+ */
+func GetMixedScripts (name string) (scripts []string) {
+        // ASCII letters are all Latin, so an ASCII name cannot mix scripts
+        if isASCII(name) { return nil }
+
+        found := make(map[string] bool)
+        for _, ch := range name {
+                script := GetScript(ch)
+                if script == "" { continue }
+                found[script] = true
+        }
+
+        for script := range found {
+                scripts = append(scripts, script)
+        }
+        sort.Strings(scripts)
+        if len(scripts) < 2 { return nil }
+
+        for _, mix := range scriptMixes {
+                if containsAll(mix, scripts) { return nil }
+        }
+
+        return scripts
+}
+
+/* scriptRange is a range of runes that all belong to the same script.
+ */
+type scriptRange struct {
+        low    rune
+        high   rune
+        script string
+}
+
+/* scriptRanges holds the ranges of every script other than Common and
+ * Inherited, sorted so that the script of a rune can be found with a binary
+ * search instead of checking every script in turn. It is built the first time
+ * it is needed.
+ */
+var scriptRanges []scriptRange
+var scriptRangesOnce sync.Once
+
+/* GetScript returns the name of the script that a rune belongs to, or an empty
+ * string if it is shared between scripts, like digits and punctuation are.
+ */
+func GetScript (ch rune) (script string) {
+        scriptRangesOnce.Do(buildScriptRanges)
+        
+        index := sort.Search(len(scriptRanges), func (index int) bool {
+                return scriptRanges[index].high >= ch
+        })
+        if index == len(scriptRanges) { return "" }
+
+        found := scriptRanges[index]
+        if ch < found.low { return "" }
+        return found.script
+}
+
+func buildScriptRanges () {
+        add := func (low, high, stride rune, script string) {
+                if stride == 1 {
+                        scriptRanges = append (scriptRanges, scriptRange {
+                                low: low, high: high, script: script,
+                        })
+                        return
+                }
+                
+                for ch := low; ch <= high; ch += stride {
+                        scriptRanges = append (scriptRanges, scriptRange {
+                                low: ch, high: ch, script: script,
+                        })
+                }
+        }
+        
+        for script, table := range unicode.Scripts {
+                if script == "Common" || script == "Inherited" { continue }
                 
-                // otherwise, return false
-                return false
+                for _, span := range table.R16 {
+                        add (
+                                rune(span.Lo), rune(span.Hi),
+                                rune(span.Stride), script)
+                }
+                for _, span := range table.R32 {
+                        add (
+                                rune(span.Lo), rune(span.Hi),
+                                rune(span.Stride), script)
+                }
         }
 
+        sort.Slice(scriptRanges, func (left, right int) bool {
+                return scriptRanges[left].low < scriptRanges[right].low
+        })
+}
+
+func isASCII (name string) (ascii bool) {
+        for index := 0; index < len(name); index ++ {
+                if name[index] >= 0x80 { return false }
+        }
+        return true
+}
+
+func containsAll (list []string, items []string) (found bool) {
+        for _, item := range items {
+                found = false
+                for _, element := range list {
+                        if element == item {
+                                found = true
+                                break
+                        }
+                }
+                if !found { return false }
+        }
         return true
 }
 
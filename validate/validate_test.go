@@ -0,0 +1,80 @@
+package validate
+
+import "testing"
+import "unicode"
+
+func TestGetScript (test *testing.T) {
+        // check the runes at and around the edges of every range, since that
+        // is where a binary search would go wrong
+        var edges []rune
+        for _, table := range unicode.Scripts {
+                for _, span := range table.R16 {
+                        edges = append (
+                                edges,
+                                rune(span.Lo) - 1, rune(span.Lo),
+                                rune(span.Lo) + rune(span.Stride),
+                                rune(span.Hi), rune(span.Hi) + 1)
+                }
+                for _, span := range table.R32 {
+                        edges = append (
+                                edges,
+                                rune(span.Lo) - 1, rune(span.Lo),
+                                rune(span.Lo) + rune(span.Stride),
+                                rune(span.Hi), rune(span.Hi) + 1)
+                }
+        }
+
+        for _, ch := range edges {
+                expected := ""
+                for script, table := range unicode.Scripts {
+                        if script == "Common" || script == "Inherited" {
+                                continue
+                        }
+                        if unicode.Is(table, ch) {
+                                expected = script
+                                break
+                        }
+                }
+
+                if GetScript(ch) != expected {
+                        test.Fatalf (
+                                "%U is in %q, expected %q",
+                                ch, GetScript(ch), expected)
+                }
+        }
+}
+
+func TestGetMixedScripts (test *testing.T) {
+        cases := map[string] []string {
+                "password":  nil,
+                "größe":     nil,
+                "dataサイズ":  nil,
+                "값2":        nil,
+                "pаssword":  { "Cyrillic", "Latin" },
+                "Αlpha":     { "Greek", "Latin" },
+        }
+
+        for name, expected := range cases {
+                scripts := GetMixedScripts(name)
+                if len(scripts) != len(expected) {
+                        test.Errorf (
+                                "%q mixes %q, expected %q",
+                                name, scripts, expected)
+                        continue
+                }
+                for index := range scripts {
+                        if scripts[index] == expected[index] { continue }
+                        test.Errorf (
+                                "%q mixes %q, expected %q",
+                                name, scripts, expected)
+                        break
+                }
+        }
+}
+
+func BenchmarkGetMixedScripts (benchmark *testing.B) {
+        for index := 0; index < benchmark.N; index ++ {
+                GetMixedScripts("someLongerName")
+                GetMixedScripts("größeDerDatei")
+        }
+}